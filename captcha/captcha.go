@@ -1,7 +1,7 @@
 package captcha
 
 import (
-	"strings"
+	"encoding/base64"
 	"time"
 
 	"github.com/mojocn/base64Captcha"
@@ -9,55 +9,116 @@ import (
 
 type Captcha struct {
 	dirver base64Captcha.Driver
-	store  base64Captcha.Store
+	store  Store
+	typ    string //验证码类型名, 保存在记录中, 校验时比对, 防止用其他类型的验证码ID 校验
+	err    error  //类型选项错误 (如字体加载失败, 滑块尺寸过小), 生成时返回
 
-	length int //验证码长度
+	length     int           //验证码长度
+	expiration time.Duration //有效期
+
+	maxAttempts   int  //每个验证码最多可尝试次数, 0表示不限
+	keepOnFailure bool //校验失败时是否保留验证码
+
+	match  func(answer, code string) error //答案比对, 不通过时返回 ErrMismatch
+	slider *sliderDriver                   //滑块验证码 (非 base64Captcha 驱动)
+	click  *clickDriver                    //点选验证码 (非 base64Captcha 驱动)
+	pow    *powDriver                      //工作量证明验证码
+
+	stateless *stateless //无状态令牌模式
+
+	format ImageFormat  //图片输出格式
+	output OutputOption //图片输出选项
 }
 
 // 创建验证码实例
 //   - {size} 验证码长度
 //   - {exp} 验证码有效期,单位/秒
-//   - {dirverType} 验证码类型,可选,默认(数值+字母组合)
+//   - {dirverType} 验证码类型及其他选项 (如 WithStore),可选,默认(数值+字母组合)
 func New(size, exp int, dirverType ...CaptchaType) *Captcha {
 	ins := new(Captcha)
 	ins.length = size
-	ins.store = base64Captcha.NewMemoryStore(
-		base64Captcha.GCLimitNumber,
-		time.Duration(exp)*time.Second,
-	)
-	if len(dirverType) != 0 {
-		dirverType[0](ins)
-	} else {
+	ins.expiration = time.Duration(exp) * time.Second
+	ins.store = NewMemoryStore()
+	ins.match = matchText
+	for _, fn := range dirverType {
+		fn(ins)
+	}
+	if ins.dirver == nil && ins.slider == nil && ins.click == nil && ins.pow == nil {
 		WithTypeString(120, 40)(ins)
 	}
 	return ins
 }
 
+// 验证码种类
+type Kind int
+
+const (
+	KindImage  Kind = iota //图片文字类 (WithTypeString, WithTypeDigit, WithTypeMath, WithTypeChinese)
+	KindAudio              //音频 (WithTypeAudio)
+	KindSlider             //滑块 (WithTypeSlider), 使用 MakeSlider 生成
+	KindClick              //点选 (WithTypeClick), 使用 MakeClick 生成
+	KindPoW                //工作量证明 (WithTypePoW)
+)
+
+// Kind 验证码种类
+func (c *Captcha) Kind() Kind {
+	switch {
+	case c.slider != nil:
+		return KindSlider
+	case c.click != nil:
+		return KindClick
+	case c.pow != nil:
+		return KindPoW
+	}
+	if _, ok := c.dirver.(*base64Captcha.DriverAudio); ok {
+		return KindAudio
+	}
+	return KindImage
+}
+
 // Make 生成验证码
-//   - {withFormatPrefix} 是否携带图片格式前缀
+//   - {withFormatPrefix} 是否携带格式前缀, 如 "data:image/png;base64,", 音频为 "data:audio/wav;base64,"
 //
 // return:
 //   - {idKey} 验证码ID 校验时要用
-//   - {b64Str} 验证码图片 base64 字串
+//   - {b64Str} 验证码图片/音频 base64 字串, 工作量证明验证码 (WithTypePoW) 为挑战字串
 func (c *Captcha) Make(withFormatPrefix bool) (idKey, b64Str string, err error) {
-	idKey, question, answer := c.dirver.GenerateIdQuestionAnswer()
-	if err = c.store.Set(idKey, answer); err != nil {
-		return
+	if c.pow != nil {
+		ch, err := c.MakePoW("")
+		if err != nil {
+			return "", "", err
+		}
+		return ch.Id, ch.Challenge, nil
 	}
-	item, err := c.dirver.DrawCaptcha(question)
+	idKey, data, mime, err := c.MakeBytes()
 	if err != nil {
 		return
 	}
-	b64Str = item.EncodeB64string()
-	if !withFormatPrefix {
-		b64Str = strings.TrimPrefix(b64Str, "data:image/png;base64,")
+	b64Str = base64.StdEncoding.EncodeToString(data)
+	if withFormatPrefix {
+		b64Str = "data:" + mime + ";base64," + b64Str
 	}
 	return
 }
 
-// Check 校验验证码
+// Check 校验验证码 (不区分大小写)
 //   - {idKey} 验证码ID
 //   - {code} 用户提交的验证码
+//
+// 注: 需区分失败原因时使用 Verify
 func (c *Captcha) Check(idKey, code string) bool {
-	return c.store.Verify(idKey, strings.ToUpper(code), true)
+	res, _ := c.Verify(idKey, code)
+	return res.OK
+}
+
+// resetType 清除已设置的验证码类型, 各类型选项设置前调用
+//   - {typ} 新的类型名, 如 "digit", "slider"
+func (c *Captcha) resetType(typ string) {
+	c.typ = typ
+	c.err = nil
+	c.dirver = nil
+	c.slider = nil
+	c.click = nil
+	c.pow = nil
+	c.match = matchText
 }
@@ -0,0 +1,277 @@
+package captcha
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/golang/freetype"
+	"github.com/golang/freetype/truetype"
+	"github.com/mojocn/base64Captcha"
+)
+
+// 点选验证码选项参数
+type ClickOption struct {
+	Decoys      int           //干扰字数(绘制但无需点击),默认1
+	FontSize    float64       //字号(像素),默认高度的1/5,最小16
+	Source      string        //取值源,默认 base64Captcha.TxtChineseCharaters
+	Backgrounds []image.Image //背景图池,随机选用并缩放至宽高,为空时随机生成
+	Tolerance   int           //点击位置允许超出文字区域的像素,默认4
+}
+
+// ClickChallenge 点选验证码
+type ClickChallenge struct {
+	Id     string   `json:"id"`     //验证码ID 校验时要用
+	Image  string   `json:"image"`  //图片 base64 字串 (png)
+	Prompt []string `json:"prompt"` //需依次点击的文字
+	Width  int      `json:"width"`  //图片宽
+	Height int      `json:"height"` //图片高
+}
+
+// ClickPoint 点击坐标 (相对图片左上角)
+type ClickPoint struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// 点选验证码, 在背景上随机位置与角度绘制文字, 需按提示顺序点击
+//   - {w},{h} 宽高
+//   - {opt} 可选项参数
+//
+// 需点击的字数为验证码长度(New 的 {size}), 字体使用内置 wqy-microhei.ttc (同 WithTypeChinese);
+// 需使用 MakeClick 生成, VerifyClick 校验; 也可用 Verify 校验, {code} 格式为 "x1,y1;x2,y2;...";
+// 取值源字数少于需点击字数与干扰字数之和时 MakeClick 返回错误
+func WithTypeClick(w, h int, opt ...*ClickOption) CaptchaType {
+	return func(c *Captcha) {
+		var o ClickOption
+		if len(opt) != 0 && opt[0] != nil {
+			o = *opt[0]
+		}
+		if o.Decoys < 0 {
+			o.Decoys = 0
+		} else if o.Decoys == 0 {
+			o.Decoys = 1
+		}
+		if o.FontSize <= 0 {
+			o.FontSize = max(float64(h)/5, 16)
+		}
+		if o.Source == "" {
+			o.Source = base64Captcha.TxtChineseCharaters
+		}
+		if o.Tolerance <= 0 {
+			o.Tolerance = 4
+		}
+		d := &clickDriver{width: w, height: h, count: max(c.length, 1), opt: o}
+		c.resetType("click")
+		if n := d.count + d.opt.Decoys; len([]rune(o.Source)) < n {
+			c.err = fmt.Errorf("captcha: 点选验证码取值源字数应不少于 %d", n)
+		}
+		c.click = d
+		c.match = d.matchCode
+	}
+}
+
+// MakeClick 生成点选验证码
+//   - {withFormatPrefix} 图片是否携带格式前缀
+func (c *Captcha) MakeClick(withFormatPrefix bool) (*ClickChallenge, error) {
+	return c.MakeClickContext(context.Background(), withFormatPrefix)
+}
+
+// MakeClickContext 同 MakeClick, 可传入上下文用于存储操作
+func (c *Captcha) MakeClickContext(ctx context.Context, withFormatPrefix bool) (*ClickChallenge, error) {
+	if c.click == nil {
+		return nil, ErrUnsupported
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	ch, boxes, err := c.click.draw(withFormatPrefix)
+	if err != nil {
+		return nil, err
+	}
+	ch.Id = base64Captcha.RandomId()
+	if ch.Id, err = c.save(ctx, ch.Id, encodeBoxes(boxes)); err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// VerifyClick 校验点选验证码
+//   - {idKey} 验证码ID
+//   - {points} 按顺序的点击坐标
+func (c *Captcha) VerifyClick(idKey string, points []ClickPoint) (Result, error) {
+	return c.VerifyClickContext(context.Background(), idKey, points)
+}
+
+// VerifyClickContext 同 VerifyClick, 可传入上下文用于存储操作
+func (c *Captcha) VerifyClickContext(ctx context.Context, idKey string, points []ClickPoint) (Result, error) {
+	if c.click == nil {
+		return Result{}, ErrUnsupported
+	}
+	return c.VerifyContext(ctx, idKey, EncodeClickPoints(points))
+}
+
+// EncodeClickPoints 将点击坐标编码为 Verify 可用的 {code} 字串
+func EncodeClickPoints(points []ClickPoint) string {
+	parts := make([]string, len(points))
+	for i, p := range points {
+		parts[i] = strconv.Itoa(p.X) + "," + strconv.Itoa(p.Y)
+	}
+	return strings.Join(parts, ";")
+}
+
+// ============================================================
+
+// clickFont 点选验证码字体, 首次使用时加载
+var clickFont = sync.OnceValue(func() *truetype.Font {
+	return base64Captcha.DefaultEmbeddedFonts.LoadFontByName("fonts/wqy-microhei.ttc")
+})
+
+type clickDriver struct {
+	width, height int
+	count         int //需点击的字数
+	opt           ClickOption
+}
+
+// draw 绘制图片, 返回需点击文字的区域 (按提示顺序)
+func (d *clickDriver) draw(withFormatPrefix bool) (*ClickChallenge, []image.Rectangle, error) {
+	bg := drawBackground(d.width, d.height, d.opt.Backgrounds)
+
+	source := []rune(d.opt.Source)
+	rand.Shuffle(len(source), func(i, j int) { source[i], source[j] = source[j], source[i] })
+	chars := source[:d.count+d.opt.Decoys]
+
+	// 文字图块边长留出旋转余量 (对角线约为字号的 1.41 倍)
+	tile := int(math.Ceil(d.opt.FontSize * 1.5))
+	boxes := make([]image.Rectangle, 0, len(chars))
+	for _, ch := range chars {
+		box, ok := d.place(tile, boxes)
+		if !ok {
+			return nil, nil, fmt.Errorf("captcha: 点选验证码尺寸 %dx%d 无法容纳 %d 个文字", d.width, d.height, len(chars))
+		}
+		mask, err := d.glyph(ch, tile, (rand.Float64()*2-1)*math.Pi*40/180)
+		if err != nil {
+			return nil, nil, err
+		}
+		shadow := box.Add(image.Pt(1, 1))
+		draw.DrawMask(bg, shadow, image.NewUniform(color.RGBA{0, 0, 0, 160}), image.Point{}, mask, image.Point{}, draw.Over)
+		draw.DrawMask(bg, box, image.NewUniform(brightColor()), image.Point{}, mask, image.Point{}, draw.Over)
+		boxes = append(boxes, box)
+	}
+
+	ch := &ClickChallenge{Width: d.width, Height: d.height}
+	for _, r := range chars[:d.count] {
+		ch.Prompt = append(ch.Prompt, string(r))
+	}
+	var err error
+	if ch.Image, err = encodePNG(bg, withFormatPrefix); err != nil {
+		return nil, nil, err
+	}
+	return ch, boxes[:d.count], nil
+}
+
+// place 随机选取与已有区域不重叠的位置
+func (d *clickDriver) place(tile int, placed []image.Rectangle) (image.Rectangle, bool) {
+	if tile >= d.width || tile >= d.height {
+		return image.Rectangle{}, false
+	}
+	for try := 0; try < 200; try++ {
+		p := image.Pt(rand.IntN(d.width-tile), rand.IntN(d.height-tile))
+		box := image.Rectangle{Min: p, Max: p.Add(image.Pt(tile, tile))}
+		ok := true
+		for _, b := range placed {
+			if box.Overlaps(b.Inset(-2)) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return box, true
+		}
+	}
+	return image.Rectangle{}, false
+}
+
+// glyph 绘制旋转 {angle} 弧度后的文字蒙版
+func (d *clickDriver) glyph(ch rune, tile int, angle float64) (*image.Alpha, error) {
+	src := image.NewAlpha(image.Rect(0, 0, tile, tile))
+	fc := freetype.NewContext()
+	fc.SetDPI(72)
+	fc.SetFont(clickFont())
+	fc.SetFontSize(d.opt.FontSize)
+	fc.SetClip(src.Bounds())
+	fc.SetDst(src)
+	fc.SetSrc(image.Opaque)
+	offset := (float64(tile) - d.opt.FontSize) / 2
+	if _, err := fc.DrawString(string(ch), freetype.Pt(int(offset), int(offset+d.opt.FontSize*0.88))); err != nil {
+		return nil, err
+	}
+
+	// 以图块中心逆向映射旋转
+	dst := image.NewAlpha(src.Bounds())
+	c := float64(tile) / 2
+	sin, cos := math.Sincos(angle)
+	for y := 0; y < tile; y++ {
+		for x := 0; x < tile; x++ {
+			dx, dy := float64(x)+0.5-c, float64(y)+0.5-c
+			sx, sy := int(math.Floor(dx*cos+dy*sin+c)), int(math.Floor(-dx*sin+dy*cos+c))
+			if sx >= 0 && sy >= 0 && sx < tile && sy < tile {
+				dst.SetAlpha(x, y, src.AlphaAt(sx, sy))
+			}
+		}
+	}
+	return dst, nil
+}
+
+// matchCode 比对点击坐标, {code} 格式为 "x1,y1;x2,y2;..."
+func (d *clickDriver) matchCode(answer, code string) error {
+	boxes, err := decodeBoxes(answer)
+	if err != nil {
+		return err
+	}
+	parts := strings.Split(code, ";")
+	if len(parts) != len(boxes) {
+		return ErrMismatch
+	}
+	for i, part := range parts {
+		xs, ys, ok := strings.Cut(part, ",")
+		if !ok {
+			return ErrMismatch
+		}
+		x, xErr := strconv.Atoi(strings.TrimSpace(xs))
+		y, yErr := strconv.Atoi(strings.TrimSpace(ys))
+		if xErr != nil || yErr != nil || !image.Pt(x, y).In(boxes[i].Inset(-d.opt.Tolerance)) {
+			return ErrMismatch
+		}
+	}
+	return nil
+}
+
+// encodeBoxes 文字区域编码为 "x0,y0,x1,y1;..."
+func encodeBoxes(boxes []image.Rectangle) string {
+	parts := make([]string, len(boxes))
+	for i, b := range boxes {
+		parts[i] = fmt.Sprintf("%d,%d,%d,%d", b.Min.X, b.Min.Y, b.Max.X, b.Max.Y)
+	}
+	return strings.Join(parts, ";")
+}
+
+func decodeBoxes(s string) ([]image.Rectangle, error) {
+	parts := strings.Split(s, ";")
+	boxes := make([]image.Rectangle, len(parts))
+	for i, part := range parts {
+		var b image.Rectangle
+		if _, err := fmt.Sscanf(part, "%d,%d,%d,%d", &b.Min.X, &b.Min.Y, &b.Max.X, &b.Max.Y); err != nil {
+			return nil, fmt.Errorf("captcha: 记录格式错误: %w", err)
+		}
+		boxes[i] = b
+	}
+	return boxes, nil
+}
@@ -0,0 +1,61 @@
+package captcha_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ackcoder/go-mods/captcha"
+)
+
+// clickCenters 从存储记录 "x0,y0,x1,y1;..." 中计算各文字区域中心点
+func clickCenters(t *testing.T, store captcha.Store, id string) []captcha.ClickPoint {
+	t.Helper()
+	var points []captcha.ClickPoint
+	for _, box := range strings.Split(answerOf(t, store, id), ";") {
+		var x0, y0, x1, y1 int
+		if _, err := fmt.Sscanf(box, "%d,%d,%d,%d", &x0, &y0, &x1, &y1); err != nil {
+			t.Fatal(err)
+		}
+		points = append(points, captcha.ClickPoint{X: (x0 + x1) / 2, Y: (y0 + y1) / 2})
+	}
+	return points
+}
+
+func TestClick(t *testing.T) {
+	store := captcha.NewMemoryStore()
+	ins := captcha.New(3, 60, captcha.WithStore(store), captcha.WithTypeClick(300, 160))
+
+	ch, err := ins.MakeClick(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(ch.Id, ch.Prompt, len(ch.Image))
+	if len(ch.Prompt) != 3 || !strings.HasPrefix(ch.Image, "data:image/png;base64,") {
+		t.Error("生成结果错误", ch.Prompt)
+	}
+
+	points := clickCenters(t, store, ch.Id)
+	reversed := []captcha.ClickPoint{points[2], points[1], points[0]}
+	if _, err = ins.VerifyClick(ch.Id, reversed); !errors.Is(err, captcha.ErrMismatch) {
+		t.Error("点击顺序错误理应不通过", err)
+	}
+
+	ch, _ = ins.MakeClick(false)
+	if res, err := ins.VerifyClick(ch.Id, clickCenters(t, store, ch.Id)); err != nil || !res.OK {
+		t.Error("理应校验通过", res, err)
+	}
+
+	ch, _ = ins.MakeClick(false)
+	if !ins.Check(ch.Id, captcha.EncodeClickPoints(clickCenters(t, store, ch.Id))) {
+		t.Error("Check 理应校验通过")
+	}
+}
+
+func TestClickShortSource(t *testing.T) {
+	ins := captcha.New(4, 60, captcha.WithTypeClick(300, 150, &captcha.ClickOption{Source: "一二"}))
+	if _, err := ins.MakeClick(false); err == nil {
+		t.Error("取值源字数不足理应返回错误")
+	}
+}
@@ -0,0 +1,195 @@
+package captcha
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// GuardLevel 风控等级
+type GuardLevel struct {
+	Failures int      //窗口内失败次数达到该值时启用
+	Captcha  *Captcha //该等级使用的验证码
+}
+
+// 风控选项参数
+type GuardOption struct {
+	Window  time.Duration //失败次数统计的滑动窗口,默认15分钟
+	Prefix  string        //存储键前缀,默认 "guard:"
+	Buckets int           //滑动窗口分桶数,越多越精确但读取次数越多,默认10
+}
+
+// Guard 基于风险的验证码
+//
+// 按键 (如 IP, 用户名) 统计滑动窗口内的失败次数, 次数达到对应等级时才需要验证码,
+// 失败越多等级越高 (如 数字 -> 数值字母 -> 滑块), 成功后清零
+//
+// 用法:
+//
+//	_, c, _ := guard.Level(ctx, ip, username)
+//	if c != nil {
+//		// 需要验证码: 用 c 生成并下发
+//	}
+//	// 提交时由服务端重新计算等级并校验, 不信任客户端提交的等级
+//	if _, err := guard.Verify(ctx, id, code, ip, username); err != nil {
+//		guard.Fail(ctx, ip, username)
+//		return
+//	}
+//	if 登录失败 { guard.Fail(ctx, ip, username) } else { guard.Success(ctx, ip, username) }
+//
+// 注: 失败次数按时间分桶, 使用 Store.Incr 原子计数, 窗口边界精度为 Window/Buckets
+type Guard struct {
+	store  Store
+	levels []GuardLevel
+	opt    GuardOption
+	bucket time.Duration //单个分桶时长
+}
+
+// NewGuard 创建风控验证码
+//   - {store} 失败记录存储, 可与验证码共用 (验证码记录使用独立的键前缀, 无法以验证码ID 访问失败记录)
+//   - {levels} 风控等级, 按 Failures 升序使用
+//   - {opt} 可选项参数
+//
+// 注: 各等级的验证码共用存储时, 以其他等级验证码ID 校验会因类型不符而失败;
+// 同类型的等级 (如两个文字验证码) 应使用不同存储或不同长度
+func NewGuard(store Store, levels []GuardLevel, opt ...*GuardOption) *Guard {
+	var o GuardOption
+	if len(opt) != 0 && opt[0] != nil {
+		o = *opt[0]
+	}
+	if o.Window <= 0 {
+		o.Window = 15 * time.Minute
+	}
+	if o.Prefix == "" {
+		o.Prefix = "guard:"
+	}
+	if o.Buckets <= 0 {
+		o.Buckets = 10
+	}
+	levels = append([]GuardLevel(nil), levels...)
+	sort.SliceStable(levels, func(i, j int) bool { return levels[i].Failures < levels[j].Failures })
+	return &Guard{store: store, levels: levels, opt: o, bucket: max(o.Window/time.Duration(o.Buckets), time.Millisecond)}
+}
+
+// DefaultGuardLevels 默认风控等级: 失败3次数字验证码, 5次数值字母验证码, 8次滑块验证码
+//   - {store} 验证码存储
+//   - {exp} 验证码有效期,单位/秒
+func DefaultGuardLevels(store Store, exp int) []GuardLevel {
+	return []GuardLevel{
+		{Failures: 3, Captcha: New(4, exp, WithTypeDigit(100, 36), WithStore(store))},
+		{Failures: 5, Captcha: New(5, exp, WithTypeString(120, 40), WithStore(store))},
+		{Failures: 8, Captcha: New(0, exp, WithTypeSlider(300, 150), WithStore(store))},
+	}
+}
+
+// Level 当前风控等级
+//   - {keys} 风控键, 如 IP, 用户名, 取各键失败次数的最大值
+//
+// return:
+//   - {level} 等级序号, 无需验证码时为 -1
+//   - {c} 该等级的验证码, 无需验证码时为 nil
+func (g *Guard) Level(ctx context.Context, keys ...string) (level int, c *Captcha, err error) {
+	failures, err := g.Failures(ctx, keys...)
+	if err != nil {
+		return -1, nil, err
+	}
+	level = -1
+	for i, l := range g.levels {
+		if failures >= l.Failures {
+			level, c = i, l.Captcha
+		}
+	}
+	return level, c, nil
+}
+
+// Captcha 指定等级的验证码, 等级不存在时返回 nil
+func (g *Guard) Captcha(level int) *Captcha {
+	if level < 0 || level >= len(g.levels) {
+		return nil
+	}
+	return g.levels[level].Captcha
+}
+
+// Verify 校验验证码, 按当前失败次数重新计算等级, 用该等级的验证码校验
+//   - {idKey} 验证码ID
+//   - {code} 用户提交的验证码
+//   - {keys} 风控键, 同 Level
+//
+// 无需验证码时直接返回 OK 为 true 的结果; 提交的验证码ID 不是当前等级的验证码生成的
+// (如失败次数增加后仍提交低等级的验证码) 时返回 ErrNotFound
+//
+// 注: 滑块验证码需提交拖动轨迹, 使用 VerifySlider
+func (g *Guard) Verify(ctx context.Context, idKey, code string, keys ...string) (Result, error) {
+	_, c, err := g.Level(ctx, keys...)
+	if err != nil || c == nil {
+		return Result{OK: err == nil, Remaining: -1}, err
+	}
+	return c.VerifyContext(ctx, idKey, code)
+}
+
+// VerifySlider 同 Verify, 当前等级为滑块验证码时校验横向位置与拖动轨迹, 其他等级返回 ErrUnsupported
+func (g *Guard) VerifySlider(ctx context.Context, idKey string, x int, track []TrackPoint, keys ...string) (Result, error) {
+	_, c, err := g.Level(ctx, keys...)
+	if err != nil || c == nil {
+		return Result{OK: err == nil, Remaining: -1}, err
+	}
+	return c.VerifySliderContext(ctx, idKey, x, track)
+}
+
+// Failures 窗口内的失败次数, 取各键的最大值
+func (g *Guard) Failures(ctx context.Context, keys ...string) (int, error) {
+	now := g.bucketOf(time.Now())
+	n := 0
+	for _, key := range keys {
+		sum := 0
+		for b := now - int64(g.opt.Buckets) + 1; b <= now; b++ {
+			v, err := g.store.Get(ctx, g.key(key, b))
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return 0, err
+			}
+			c, _ := strconv.Atoi(v)
+			sum += c
+		}
+		n = max(n, sum)
+	}
+	return n, nil
+}
+
+// Fail 记录一次失败, 返回记录后的失败次数 (各键最大值)
+func (g *Guard) Fail(ctx context.Context, keys ...string) (int, error) {
+	now := g.bucketOf(time.Now())
+	for _, key := range keys {
+		if _, err := g.store.Incr(ctx, g.key(key, now), g.opt.Window+g.bucket); err != nil {
+			return 0, err
+		}
+	}
+	return g.Failures(ctx, keys...)
+}
+
+// Success 成功后清零失败次数
+func (g *Guard) Success(ctx context.Context, keys ...string) error {
+	now := g.bucketOf(time.Now())
+	for _, key := range keys {
+		for b := now - int64(g.opt.Buckets) + 1; b <= now; b++ {
+			if err := g.store.Delete(ctx, g.key(key, b)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// bucketOf 时间所在的分桶序号
+func (g *Guard) bucketOf(t time.Time) int64 {
+	return t.UnixNano() / int64(g.bucket)
+}
+
+// key 分桶计数的存储键
+func (g *Guard) key(key string, bucket int64) string {
+	return g.opt.Prefix + key + ":" + strconv.FormatInt(bucket, 10)
+}
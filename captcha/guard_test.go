@@ -0,0 +1,142 @@
+package captcha_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ackcoder/go-mods/captcha"
+)
+
+func TestGuard(t *testing.T) {
+	ctx := context.Background()
+	store := captcha.NewMemoryStore()
+	g := captcha.NewGuard(store, captcha.DefaultGuardLevels(store, 60))
+
+	want := map[int]captcha.Kind{3: captcha.KindImage, 5: captcha.KindImage, 8: captcha.KindSlider}
+	for i := 1; i <= 8; i++ {
+		if _, err := g.Fail(ctx, "1.2.3.4", "alice"); err != nil {
+			t.Fatal(err)
+		}
+		level, c, err := g.Level(ctx, "1.2.3.4", "bob")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Log(i, level)
+		if i < 3 && c != nil {
+			t.Error("失败次数较少时无需验证码", i)
+		}
+		if kind, ok := want[i]; ok && (c == nil || c.Kind() != kind || g.Captcha(level) != c) {
+			t.Error("等级错误", i, level)
+		}
+	}
+
+	if err := g.Success(ctx, "1.2.3.4"); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := g.Failures(ctx, "1.2.3.4"); n != 0 {
+		t.Error("成功后理应清零", n)
+	}
+	if n, _ := g.Failures(ctx, "alice"); n != 8 {
+		t.Error("其他键不受影响", n)
+	}
+}
+
+func TestGuardWindow(t *testing.T) {
+	ctx := context.Background()
+	store := captcha.NewMemoryStore()
+	g := captcha.NewGuard(store, captcha.DefaultGuardLevels(store, 60), &captcha.GuardOption{Window: 50 * time.Millisecond})
+	g.Fail(ctx, "k")
+	g.Fail(ctx, "k")
+	time.Sleep(30 * time.Millisecond)
+	g.Fail(ctx, "k")
+	time.Sleep(30 * time.Millisecond)
+	if n, _ := g.Failures(ctx, "k"); n != 1 {
+		t.Error("窗口外的失败理应不计", n)
+	}
+}
+
+func TestGuardVerify(t *testing.T) {
+	ctx := context.Background()
+	store := captcha.NewMemoryStore()
+	g := captcha.NewGuard(store, captcha.DefaultGuardLevels(store, 60))
+
+	if res, err := g.Verify(ctx, "", "", "k"); err != nil || !res.OK {
+		t.Error("无需验证码时理应通过", res, err)
+	}
+
+	for i := 0; i < 3; i++ {
+		g.Fail(ctx, "k")
+	}
+	_, c, _ := g.Level(ctx, "k")
+	id, _, _ := c.Make(false)
+	digit := answerOf(t, store, id)
+	if res, err := g.Verify(ctx, id, digit, "k"); err != nil || !res.OK {
+		t.Error("理应校验通过", res, err)
+	}
+
+	// 已升至滑块等级, 降级使用数字验证码不通过
+	for i := 0; i < 5; i++ {
+		g.Fail(ctx, "k")
+	}
+	id, _, _ = g.Captcha(0).Make(false)
+	if _, err := g.Verify(ctx, id, answerOf(t, store, id), "k"); !errors.Is(err, captcha.ErrNotFound) {
+		t.Error("降级理应不通过", err)
+	}
+
+	// 数字答案不能作为滑块横向位置
+	slider := g.Captcha(2)
+	id, _, _ = g.Captcha(0).Make(false)
+	if _, err := slider.Verify(id, answerOf(t, store, id)); !errors.Is(err, captcha.ErrNotFound) {
+		t.Error("其他类型的验证码ID 理应不通过", err)
+	}
+
+	ch, _ := slider.MakeSlider(false)
+	x, _ := strconv.Atoi(answerOf(t, store, ch.Id))
+	if res, err := g.VerifySlider(ctx, ch.Id, x, humanTrack(x), "k"); err != nil || !res.OK {
+		t.Error("理应校验通过", res, err)
+	}
+}
+
+func TestGuardConcurrentFail(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeRESP(t, "")
+	store := captcha.NewRESPStore(f.ln.Addr().String())
+	defer store.Close()
+	g := captcha.NewGuard(store, captcha.DefaultGuardLevels(store, 60))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := g.Fail(ctx, "k"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n, _ := g.Failures(ctx, "k"); n != 50 {
+		t.Error("并发失败计数理应精确", n)
+	}
+}
+
+func TestGuardVerifyCounterKey(t *testing.T) {
+	ctx := context.Background()
+	store := captcha.NewMemoryStore()
+	g := captcha.NewGuard(store, captcha.DefaultGuardLevels(store, 60))
+	for i := 0; i < 6; i++ {
+		g.Fail(ctx, "ip")
+	}
+	// 以失败计数的键作为验证码ID 提交, 不应删除计数 (默认分桶时长 90 秒)
+	now := time.Now().UnixNano() / int64(90*time.Second)
+	for b := now - 10; b <= now+1; b++ {
+		g.Verify(ctx, "guard:ip:"+strconv.FormatInt(b, 10), "x", "ip")
+	}
+	if n, _ := g.Failures(ctx, "ip"); n != 6 {
+		t.Error("失败计数不应被验证码校验删除", n)
+	}
+}
@@ -0,0 +1,228 @@
+// Package httpcaptcha 验证码 HTTP 接口与中间件
+//
+// 用法:
+//
+//	ins := captcha.New(4, 120)
+//	mux.Handle("GET /captcha", httpcaptcha.Handler(ins))
+//	mux.Handle("POST /login", httpcaptcha.Middleware(ins)(loginHandler))
+package httpcaptcha
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/ackcoder/go-mods/captcha"
+)
+
+// 选项参数
+type Option struct {
+	IdHeader    string //验证码ID 请求头, 默认 "X-Captcha-Id"; 原始图片输出时也通过该响应头返回ID
+	CodeHeader  string //验证码请求头, 默认 "X-Captcha-Code"
+	TrackHeader string //滑块拖动轨迹请求头, 默认 "X-Captcha-Track"
+	IdField     string //验证码ID 表单/查询字段, 默认 "captcha_id"
+	CodeField   string //验证码表单/查询字段, 默认 "captcha_code"
+	TrackField  string //滑块拖动轨迹表单/查询字段, 默认 "captcha_track"
+
+	// ClientKey 工作量证明验证码的客户端标识 (用于自适应难度), 默认取请求来源IP
+	ClientKey func(r *http.Request) string
+	// ErrorHandler 错误响应, 默认输出 JSON: {"code": "captcha_mismatch", "message": "..."}
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, status int, code string, err error)
+}
+
+func takeOption(opt ...*Option) Option {
+	var o Option
+	if len(opt) != 0 && opt[0] != nil {
+		o = *opt[0]
+	}
+	if o.IdHeader == "" {
+		o.IdHeader = "X-Captcha-Id"
+	}
+	if o.CodeHeader == "" {
+		o.CodeHeader = "X-Captcha-Code"
+	}
+	if o.TrackHeader == "" {
+		o.TrackHeader = "X-Captcha-Track"
+	}
+	if o.IdField == "" {
+		o.IdField = "captcha_id"
+	}
+	if o.CodeField == "" {
+		o.CodeField = "captcha_code"
+	}
+	if o.TrackField == "" {
+		o.TrackField = "captcha_track"
+	}
+	if o.ClientKey == nil {
+		o.ClientKey = remoteIP
+	}
+	if o.ErrorHandler == nil {
+		o.ErrorHandler = writeError
+	}
+	return o
+}
+
+// 生成验证码的响应
+type response struct {
+	Id    string `json:"id"`
+	Image string `json:"image"` //data URI, 如 "data:image/png;base64,..."
+	Mime  string `json:"mime"`
+}
+
+// Handler 生成验证码的接口
+//   - {c} 验证码实例
+//   - {opt} 可选项参数
+//
+// 默认输出 JSON: {"id": "...", "image": "data:image/png;base64,...", "mime": "image/png"};
+// 请求参数 format=raw 时直接输出图片/音频, 验证码ID 在响应头 (Option.IdHeader) 中;
+// 滑块/点选/工作量证明验证码输出对应的 SliderChallenge/ClickChallenge/PoWChallenge JSON
+func Handler(c *captcha.Captcha, opt ...*Option) http.Handler {
+	o := takeOption(opt...)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		ctx := r.Context()
+		var (
+			body any
+			err  error
+		)
+		switch c.Kind() {
+		case captcha.KindSlider:
+			body, err = c.MakeSliderContext(ctx, true)
+		case captcha.KindClick:
+			body, err = c.MakeClickContext(ctx, true)
+		case captcha.KindPoW:
+			body, err = c.MakePoWContext(ctx, o.ClientKey(r))
+		default:
+			var (
+				buf      bytes.Buffer
+				id, mime string
+			)
+			if id, mime, err = c.MakeToContext(ctx, &buf); err != nil {
+				break
+			}
+			if r.URL.Query().Get("format") == "raw" {
+				w.Header().Set("Content-Type", mime)
+				w.Header().Set(o.IdHeader, id)
+				_, _ = w.Write(buf.Bytes())
+				return
+			}
+			body = response{Id: id, Image: "data:" + mime + ";base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), Mime: mime}
+		}
+		if err != nil {
+			o.ErrorHandler(w, r, http.StatusInternalServerError, "captcha_error", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, body)
+	})
+}
+
+// Middleware 校验验证码的中间件, 未通过时输出错误响应, 不再调用后续处理
+//   - {c} 验证码实例
+//   - {opt} 可选项参数
+//
+// 验证码ID 与验证码依次从请求头, 表单/查询字段中读取; 滑块验证码为横向位置,
+// 点选验证码为 captcha.EncodeClickPoints 编码的坐标, 工作量证明验证码为 nonce;
+// 滑块验证码另需提交拖动轨迹 (同样依次从请求头, 表单/查询字段中读取),
+// 格式为 captcha.TrackPoint 的 JSON 数组, 如 [{"x":0,"y":10,"t":0},...]
+//
+//	注: 从表单读取时会解析请求体 (application/x-www-form-urlencoded 或 multipart/form-data)
+func Middleware(c *captcha.Captcha, opt ...*Option) func(http.Handler) http.Handler {
+	o := takeOption(opt...)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, code := r.Header.Get(o.IdHeader), r.Header.Get(o.CodeHeader)
+			if id == "" {
+				id = r.FormValue(o.IdField)
+			}
+			if code == "" {
+				code = r.FormValue(o.CodeField)
+			}
+			if id == "" || code == "" {
+				o.ErrorHandler(w, r, http.StatusBadRequest, "captcha_required", errors.New("缺少验证码"))
+				return
+			}
+			if err := verify(r, c, &o, id, code); err != nil {
+				status, errCode := classify(err)
+				o.ErrorHandler(w, r, status, errCode, err)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// verify 校验验证码, 滑块验证码同时校验拖动轨迹
+func verify(r *http.Request, c *captcha.Captcha, o *Option, id, code string) error {
+	if c.Kind() != captcha.KindSlider {
+		_, err := c.VerifyContext(r.Context(), id, code)
+		return err
+	}
+	x, err := strconv.Atoi(code)
+	if err != nil {
+		return fmt.Errorf("%w: 横向位置格式错误", captcha.ErrMismatch)
+	}
+	var track []captcha.TrackPoint
+	raw := r.Header.Get(o.TrackHeader)
+	if raw == "" {
+		raw = r.FormValue(o.TrackField)
+	}
+	if raw != "" {
+		if err = json.Unmarshal([]byte(raw), &track); err != nil {
+			return fmt.Errorf("%w: 拖动轨迹格式错误", captcha.ErrMismatch)
+		}
+	}
+	_, err = c.VerifySliderContext(r.Context(), id, x, track)
+	return err
+}
+
+// classify 错误对应的状态码与错误码
+func classify(err error) (status int, code string) {
+	switch {
+	case errors.Is(err, captcha.ErrNotFound):
+		return http.StatusForbidden, "captcha_not_found"
+	case errors.Is(err, captcha.ErrExpired):
+		return http.StatusForbidden, "captcha_expired"
+	case errors.Is(err, captcha.ErrTooManyAttempts):
+		return http.StatusTooManyRequests, "captcha_too_many_attempts"
+	case errors.Is(err, captcha.ErrMismatch):
+		return http.StatusForbidden, "captcha_mismatch"
+	case errors.Is(err, captcha.ErrReplayCacheFull):
+		return http.StatusServiceUnavailable, "captcha_busy"
+	}
+	return http.StatusInternalServerError, "captcha_error"
+}
+
+// 错误信息
+var messages = map[string]string{
+	"captcha_required":          "请输入验证码",
+	"captcha_not_found":         "验证码无效, 请刷新后重试",
+	"captcha_expired":           "验证码已过期, 请刷新后重试",
+	"captcha_too_many_attempts": "错误次数过多, 请刷新后重试",
+	"captcha_mismatch":          "验证码错误",
+	"captcha_busy":              "验证码服务繁忙, 请稍后重试",
+	"captcha_error":             "验证码服务异常",
+}
+
+func writeError(w http.ResponseWriter, _ *http.Request, status int, code string, _ error) {
+	writeJSON(w, status, map[string]string{"code": code, "message": messages[code]})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// remoteIP 请求来源IP (不信任代理头)
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,135 @@
+package httpcaptcha_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ackcoder/go-mods/captcha"
+	"github.com/ackcoder/go-mods/captcha/httpcaptcha"
+)
+
+func TestHandler(t *testing.T) {
+	// 取值源只有一个字符, 答案固定为 "AAAA"
+	ins := captcha.New(4, 60, captcha.WithTypeString(120, 40, &captcha.ImageOption{Source: "A"}))
+	mux := http.NewServeMux()
+	mux.Handle("GET /captcha", httpcaptcha.Handler(ins))
+	mux.Handle("POST /login", httpcaptcha.Middleware(ins, &httpcaptcha.Option{CodeField: "code"})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) }),
+	))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/captcha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body struct{ Id, Image string }
+	json.NewDecoder(resp.Body).Decode(&body)
+	resp.Body.Close()
+	if body.Id == "" || !strings.HasPrefix(body.Image, "data:image/png;base64,") {
+		t.Fatal("响应错误", body)
+	}
+
+	// 表单字段
+	resp, _ = http.PostForm(srv.URL+"/login", url.Values{"captcha_id": {body.Id}, "code": {"aaaa"}})
+	if resp.StatusCode != http.StatusOK {
+		t.Error("理应校验通过", resp.StatusCode)
+	}
+	resp.Body.Close()
+
+	// 原始图片 + 请求头
+	resp, _ = http.Get(srv.URL + "/captcha?format=raw")
+	id := resp.Header.Get("X-Captcha-Id")
+	if resp.Header.Get("Content-Type") != "image/png" || id == "" {
+		t.Error("原始图片响应错误", resp.Header)
+	}
+	resp.Body.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/login", nil)
+	req.Header.Set("X-Captcha-Id", id)
+	req.Header.Set("X-Captcha-Code", "BBBB")
+	resp, _ = http.DefaultClient.Do(req)
+	var errBody struct{ Code, Message string }
+	json.NewDecoder(resp.Body).Decode(&errBody)
+	resp.Body.Close()
+	t.Log(resp.StatusCode, errBody)
+	if resp.StatusCode != http.StatusForbidden || errBody.Code != "captcha_mismatch" {
+		t.Error("错误响应不符", resp.StatusCode, errBody)
+	}
+
+	resp, _ = http.PostForm(srv.URL+"/login", nil)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Error("缺少验证码理应返回 400", resp.StatusCode)
+	}
+	resp.Body.Close()
+}
+
+func TestHandlerSlider(t *testing.T) {
+	store := captcha.NewMemoryStore()
+	ins := captcha.New(0, 60, captcha.WithStore(store), captcha.WithTypeSlider(300, 150))
+	rec := httptest.NewRecorder()
+	httpcaptcha.Handler(ins).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/captcha", nil))
+	ch := new(captcha.SliderChallenge)
+	if err := json.Unmarshal(rec.Body.Bytes(), ch); err != nil || ch.Id == "" || ch.Piece == "" {
+		t.Fatal("滑块验证码响应错误", err)
+	}
+
+	// 记录键为 "c:" + 验证码ID, 格式为 "过期时间|已尝试次数|类型名|答案"
+	v, _ := store.Get(context.Background(), "c:"+ch.Id)
+	x := v[strings.LastIndexByte(v, '|')+1:]
+	mw := httpcaptcha.Middleware(ins)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	post := func(form url.Values) int {
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		mw.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// 缺少轨迹不通过
+	if code := post(url.Values{"captcha_id": {ch.Id}, "captcha_code": {x}}); code != http.StatusForbidden {
+		t.Error("缺少轨迹理应返回 403", code)
+	}
+
+	ch, _ = ins.MakeSliderContext(context.Background(), false)
+	v, _ = store.Get(context.Background(), "c:"+ch.Id)
+	x = v[strings.LastIndexByte(v, '|')+1:]
+	n, _ := strconv.Atoi(x)
+	track := []captcha.TrackPoint{{X: 0, Y: 10, T: 0}}
+	for i := 1; i <= 10; i++ {
+		p := float64(i) / 10
+		track = append(track, captcha.TrackPoint{X: int(float64(n) * p * (2 - p)), Y: 10 + i%3, T: int64(i * 40)})
+	}
+	b, _ := json.Marshal(track)
+	if code := post(url.Values{"captcha_id": {ch.Id}, "captcha_code": {x}, "captcha_track": {string(b)}}); code != http.StatusOK {
+		t.Error("理应校验通过", code)
+	}
+}
+
+func TestMiddlewarePoWSignature(t *testing.T) {
+	// 共用存储, 密钥不同 (如多实例未设置 Secret) 时签名无效
+	store := captcha.NewMemoryStore()
+	a := captcha.New(0, 60, captcha.WithStore(store), captcha.WithTypePoW(&captcha.PoWOption{Secret: "a", Difficulty: 4}))
+	b := captcha.New(0, 60, captcha.WithStore(store), captcha.WithTypePoW(&captcha.PoWOption{Secret: "b", Difficulty: 4}))
+	ch, err := a.MakePoW("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/login", nil)
+	req.Header.Set("X-Captcha-Id", ch.Id)
+	req.Header.Set("X-Captcha-Code", captcha.SolvePoW(ch.Prefix, ch.Difficulty))
+	rec := httptest.NewRecorder()
+	httpcaptcha.Middleware(b)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(rec, req)
+	var errBody struct{ Code string }
+	json.NewDecoder(rec.Body).Decode(&errBody)
+	if rec.Code != http.StatusForbidden || errBody.Code != "captcha_mismatch" {
+		t.Error("签名无效理应返回 403", rec.Code, errBody)
+	}
+}
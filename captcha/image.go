@@ -0,0 +1,87 @@
+package captcha
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math/rand/v2"
+
+	"github.com/nfnt/resize"
+)
+
+// drawBackground 从图池随机选取背景图并缩放至 {w}x{h}, 图池为空时随机生成
+func drawBackground(w, h int, pool []image.Image) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	if len(pool) != 0 {
+		src := pool[rand.IntN(len(pool))]
+		if b := src.Bounds(); b.Dx() != w || b.Dy() != h {
+			src = resize.Resize(uint(w), uint(h), src, resize.Bilinear)
+		}
+		draw.Draw(dst, dst.Bounds(), src, src.Bounds().Min, draw.Src)
+		return dst
+	}
+
+	// 对角渐变 + 随机色块, 使缺口/文字与背景不易被程序分离
+	from, to := randColor(), randColor()
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			t := float64(x+y) / float64(w+h)
+			dst.SetRGBA(x, y, blend(from, to, t))
+		}
+	}
+	for i := 0; i < 16; i++ {
+		c := randColor()
+		cx, cy := rand.IntN(w), rand.IntN(h)
+		r := h/8 + rand.IntN(h/3+1)
+		for y := max(cy-r, 0); y < min(cy+r, h); y++ {
+			for x := max(cx-r, 0); x < min(cx+r, w); x++ {
+				if (x-cx)*(x-cx)+(y-cy)*(y-cy) <= r*r {
+					dst.SetRGBA(x, y, blend(dst.RGBAAt(x, y), c, 0.45))
+				}
+			}
+		}
+	}
+	return dst
+}
+
+// encodePNG 编码为 base64 PNG
+func encodePNG(img image.Image, withFormatPrefix bool) (string, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", err
+	}
+	s := base64.StdEncoding.EncodeToString(buf.Bytes())
+	if withFormatPrefix {
+		s = "data:image/png;base64," + s
+	}
+	return s, nil
+}
+
+// blend 按比例 {t} 混合两种颜色
+func blend(a, b color.RGBA, t float64) color.RGBA {
+	mix := func(x, y uint8) uint8 {
+		return uint8(float64(x)*(1-t) + float64(y)*t)
+	}
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), mix(a.A, b.A)}
+}
+
+// brightColor 随机高饱和度颜色, 用于前景文字
+func brightColor() color.RGBA {
+	c := [3]uint8{uint8(200 + rand.IntN(56)), uint8(rand.IntN(256)), uint8(rand.IntN(80))}
+	rand.Shuffle(3, func(i, j int) { c[i], c[j] = c[j], c[i] })
+	return color.RGBA{c[0], c[1], c[2], 255}
+}
+
+func randColor() color.RGBA {
+	return color.RGBA{uint8(rand.IntN(256)), uint8(rand.IntN(256)), uint8(rand.IntN(256)), 255}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
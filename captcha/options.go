@@ -1,7 +1,9 @@
 package captcha
 
 import (
+	"image"
 	"image/color"
+	"io/fs"
 
 	"github.com/mojocn/base64Captcha"
 )
@@ -15,13 +17,24 @@ type ImageOption struct {
 	LineLevel int         //干扰线强度,范围1-3,-1表示不设置,默认2
 	BgColor   *color.RGBA //背景色,默认r10,g20,b50,a10
 	Source    string      //取值源
-	Font      string      //字体
+	Font      string      //字体, 未设置 FontFS 时为 base64Captcha.DefaultEmbeddedFonts 中的名称
+	Fonts     []string    //更多字体, 与 Font 合并后随机使用
+
+	// FontFS 字体文件系统, 如 os.DirFS("fonts"), embed.FS; 设置后 Font/Fonts 为其中的文件路径
+	//	注: 支持 TTF/TTC 及 TrueType 轮廓的 OTF, 不支持 CFF 轮廓的 OTF;
+	//	字体在创建时加载, 加载失败时 Make/MakeBytes/MakeTo 返回错误
+	FontFS fs.FS
+
+	Backgrounds []image.Image //背景图池, 随机选取并缩放到验证码大小, 设置后忽略 BgColor; 可用 LoadBackgrounds 加载
+	Palette     []color.Color //前景色板, 文字与干扰线替换为色板中最接近的颜色
+	Distortion  float64       //扭曲强度, 即正弦波振幅(像素), 0表示不扭曲, 建议 2-4
 }
 
 // 音频验证码
 //   - {lang} 音频语言,可用值:"en"英语,"ja"日语,"ru"俄语,"zh"中文,默认"en"
 func WithTypeAudio(lang ...string) CaptchaType {
 	return func(c *Captcha) {
+		c.resetType("audio")
 		var language string
 		if len(lang) != 0 {
 			language = lang[0]
@@ -37,19 +50,19 @@ func WithTypeAudio(lang ...string) CaptchaType {
 //   - {opt} 可选项参数
 func WithTypeChinese(w, h int, opt ...*ImageOption) CaptchaType {
 	return func(c *Captcha) {
-		noise, line, bg, source, font := takeImageOptionValues(opt...)
+		c.resetType("chinese")
+		noise, line, bg, source, _ := takeImageOptionValues(opt...)
 		if source == "" {
 			source = base64Captcha.TxtChineseCharaters
 		}
-		if font == "" {
-			font = "wqy-microhei.ttc"
-		}
-		c.dirver = base64Captcha.NewDriverChinese(
+		storage, fonts, err := takeFonts("wqy-microhei.ttc", opt...)
+		c.err = err
+		t := newTheme(w, h, bg, opt...)
+		c.dirver = t.wrap(base64Captcha.NewDriverChinese(
 			h, w,
-			noise, line, c.length, source, bg,
-			base64Captcha.DefaultEmbeddedFonts,
-			[]string{font},
-		)
+			noise, line, c.length, source, t.background(bg),
+			storage, fonts,
+		))
 	}
 }
 
@@ -57,6 +70,7 @@ func WithTypeChinese(w, h int, opt ...*ImageOption) CaptchaType {
 //   - {w},{h} 宽高,最低100x36
 func WithTypeDigit(w, h int) CaptchaType {
 	return func(c *Captcha) {
+		c.resetType("digit")
 		if w < 100 {
 			w = 100
 		}
@@ -72,19 +86,16 @@ func WithTypeDigit(w, h int) CaptchaType {
 //   - {opt} 可选项参数
 func WithTypeMath(w, h int, opt ...*ImageOption) CaptchaType {
 	return func(c *Captcha) {
-		noise, line, bg, _, font := takeImageOptionValues(opt...)
-		if font == "" {
-			font = "wqy-microhei.ttc"
-		}
-		// 注: 不使用字体
-		// c.dirver = base64Captcha.NewDriverMath(
-		// 	h, w, noise, line, bg,
-		// 	base64Captcha.DefaultEmbeddedFonts,
-		// 	[]string{font},
-		// ).ConvertFonts()
-		c.dirver = base64Captcha.NewDriverMath(
-			h, w, noise, line, bg, nil, nil,
-		)
+		c.resetType("math")
+		noise, line, bg, _, _ := takeImageOptionValues(opt...)
+		// 注: 未设置字体时与原先一致, 传入 nil 存储与 nil 列表, 使用 base64Captcha 内置的全部字体
+		storage, fonts, err := takeFonts("", opt...)
+		c.err = err
+		t := newTheme(w, h, bg, opt...)
+		c.dirver = t.wrap(base64Captcha.NewDriverMath(
+			h, w, noise, line, t.background(bg),
+			storage, fonts,
+		))
 	}
 }
 
@@ -93,19 +104,19 @@ func WithTypeMath(w, h int, opt ...*ImageOption) CaptchaType {
 //   - {opt} 可选项参数
 func WithTypeString(w, h int, opt ...*ImageOption) CaptchaType {
 	return func(c *Captcha) {
-		noise, line, bg, source, font := takeImageOptionValues(opt...)
+		c.resetType("string")
+		noise, line, bg, source, _ := takeImageOptionValues(opt...)
 		if source == "" {
 			source = base64Captcha.TxtSimpleCharaters
 		}
-		if font == "" {
-			font = "wqy-microhei.ttc"
-		}
-		c.dirver = base64Captcha.NewDriverString(
+		storage, fonts, err := takeFonts("wqy-microhei.ttc", opt...)
+		c.err = err
+		t := newTheme(w, h, bg, opt...)
+		c.dirver = t.wrap(base64Captcha.NewDriverString(
 			h, w,
-			noise, line, c.length, source, bg,
-			base64Captcha.DefaultEmbeddedFonts,
-			[]string{font},
-		).ConvertFonts()
+			noise, line, c.length, source, t.background(bg),
+			storage, fonts,
+		).ConvertFonts())
 	}
 }
 
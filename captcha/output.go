@@ -0,0 +1,170 @@
+package captcha
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"github.com/mojocn/base64Captcha"
+)
+
+// 图片输出格式
+type ImageFormat int
+
+const (
+	FormatPNG  ImageFormat = iota //PNG (默认)
+	FormatJPEG                    //JPEG, 体积较小
+	FormatGIF                     //GIF, 帧数大于1时为动图
+)
+
+// MIME 类型
+const (
+	MimePNG  = "image/png"
+	MimeJPEG = "image/jpeg"
+	MimeGIF  = "image/gif"
+	MimeWAV  = "audio/wav"
+	MimeText = "text/plain; charset=utf-8"
+)
+
+// 图片输出选项参数
+type OutputOption struct {
+	Quality int //JPEG 质量,范围1-100,默认80
+	Frames  int //GIF 帧数,默认1(静态); 各帧为同一答案的不同绘制(噪点/干扰线/位置各异),可增加识别难度
+	Delay   int //GIF 帧间隔,单位/10毫秒,默认20
+}
+
+// WithFormat 设置图片输出格式, 对音频验证码无效
+//   - {format} 输出格式
+//   - {opt} 可选项参数
+func WithFormat(format ImageFormat, opt ...*OutputOption) CaptchaType {
+	return func(c *Captcha) {
+		var o OutputOption
+		if len(opt) != 0 && opt[0] != nil {
+			o = *opt[0]
+		}
+		if o.Quality <= 0 || o.Quality > 100 {
+			o.Quality = 80
+		}
+		if o.Frames <= 0 {
+			o.Frames = 1
+		}
+		if o.Delay <= 0 {
+			o.Delay = 20
+		}
+		c.format = format
+		c.output = o
+	}
+}
+
+// MakeBytes 生成验证码
+//
+// return:
+//   - {idKey} 验证码ID 校验时要用
+//   - {data} 图片/音频原始数据, 工作量证明验证码为挑战字串
+//   - {mime} MIME 类型, 如 "image/png", "audio/wav"
+func (c *Captcha) MakeBytes() (idKey string, data []byte, mime string, err error) {
+	var buf bytes.Buffer
+	idKey, mime, err = c.MakeToContext(context.Background(), &buf)
+	return idKey, buf.Bytes(), mime, err
+}
+
+// MakeTo 生成验证码并写入 {w}
+//
+// return:
+//   - {idKey} 验证码ID 校验时要用
+//   - {mime} MIME 类型
+func (c *Captcha) MakeTo(w io.Writer) (idKey, mime string, err error) {
+	return c.MakeToContext(context.Background(), w)
+}
+
+// MakeToContext 同 MakeTo, 可传入上下文用于存储操作
+func (c *Captcha) MakeToContext(ctx context.Context, w io.Writer) (idKey, mime string, err error) {
+	if c.pow != nil {
+		ch, err := c.MakePoWContext(ctx, "")
+		if err != nil {
+			return "", "", err
+		}
+		_, err = io.WriteString(w, ch.Challenge)
+		return ch.Id, MimeText, err
+	}
+	if c.dirver == nil {
+		return "", "", ErrUnsupported
+	}
+	if c.err != nil {
+		return "", "", c.err
+	}
+
+	idKey, question, answer := c.dirver.GenerateIdQuestionAnswer()
+	item, err := c.dirver.DrawCaptcha(question)
+	if err != nil {
+		return
+	}
+	if idKey, err = c.save(ctx, idKey, answer); err != nil {
+		return
+	}
+	if _, ok := item.(*base64Captcha.ItemAudio); ok {
+		_, err = item.WriteTo(w)
+		return idKey, MimeWAV, err
+	}
+
+	switch c.format {
+	case FormatJPEG:
+		img, err := itemImage(item)
+		if err != nil {
+			return "", "", err
+		}
+		return idKey, MimeJPEG, jpeg.Encode(w, img, &jpeg.Options{Quality: c.output.Quality})
+	case FormatGIF:
+		return idKey, MimeGIF, c.encodeGIF(w, question, item)
+	default:
+		_, err = item.WriteTo(w)
+		return idKey, MimePNG, err
+	}
+}
+
+// encodeGIF 编码 GIF, 多帧时重新绘制同一题目作为后续帧
+func (c *Captcha) encodeGIF(w io.Writer, question string, first base64Captcha.Item) error {
+	anim := &gif.GIF{}
+	item := first
+	for i := 0; i < c.output.Frames; i++ {
+		if i > 0 {
+			var err error
+			if item, err = c.dirver.DrawCaptcha(question); err != nil {
+				return err
+			}
+		}
+		img, err := itemImage(item)
+		if err != nil {
+			return err
+		}
+		frame, ok := img.(*image.Paletted)
+		if !ok {
+			frame = image.NewPaletted(img.Bounds(), palette.Plan9)
+			draw.FloydSteinberg.Draw(frame, img.Bounds(), img, img.Bounds().Min)
+		}
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, c.output.Delay)
+	}
+	return gif.EncodeAll(w, anim)
+}
+
+// itemImage 取得验证码图片
+func itemImage(item base64Captcha.Item) (image.Image, error) {
+	switch i := item.(type) {
+	case *base64Captcha.ItemDigit:
+		return i.Paletted, nil
+	case *imageItem:
+		return i.img, nil
+	}
+	var buf bytes.Buffer
+	if _, err := item.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return png.Decode(&buf)
+}
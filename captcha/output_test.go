@@ -0,0 +1,58 @@
+package captcha_test
+
+import (
+	"bytes"
+	"image/gif"
+	"image/jpeg"
+	"strings"
+	"testing"
+
+	"github.com/ackcoder/go-mods/captcha"
+)
+
+func TestMakeBytes(t *testing.T) {
+	ins := captcha.New(4, 60, captcha.WithTypeString(120, 40))
+	_, data, mime, err := ins.MakeBytes()
+	if err != nil || mime != captcha.MimePNG || !bytes.HasPrefix(data, []byte("\x89PNG")) {
+		t.Error("PNG 输出错误", mime, err)
+	}
+
+	// 音频验证码前缀
+	audio := captcha.New(4, 60, captcha.WithTypeAudio())
+	_, b64Str, err := audio.Make(true)
+	if err != nil || !strings.HasPrefix(b64Str, "data:audio/wav;base64,") {
+		t.Error("音频前缀错误", b64Str[:30], err)
+	}
+	_, b64Str, _ = audio.Make(false)
+	if strings.HasPrefix(b64Str, "data:") {
+		t.Error("不应携带前缀")
+	}
+}
+
+func TestMakeFormat(t *testing.T) {
+	ins := captcha.New(4, 60, captcha.WithTypeDigit(100, 36), captcha.WithFormat(captcha.FormatJPEG))
+	var buf bytes.Buffer
+	id, mime, err := ins.MakeTo(&buf)
+	if err != nil || id == "" || mime != captcha.MimeJPEG {
+		t.Fatal(id, mime, err)
+	}
+	if _, err = jpeg.Decode(&buf); err != nil {
+		t.Error("JPEG 解码失败", err)
+	}
+
+	ins = captcha.New(4, 60, captcha.WithTypeString(120, 40), captcha.WithFormat(captcha.FormatGIF, &captcha.OutputOption{Frames: 3}))
+	_, data, mime, err := ins.MakeBytes()
+	if err != nil || mime != captcha.MimeGIF {
+		t.Fatal(mime, err)
+	}
+	anim, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil || len(anim.Image) != 3 {
+		t.Error("GIF 动图输出错误", err)
+	}
+	t.Log(len(data))
+
+	_, b64Str, _ := ins.Make(true)
+	if !strings.HasPrefix(b64Str, "data:image/gif;base64,") {
+		t.Error("GIF 前缀错误")
+	}
+}
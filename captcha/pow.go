@@ -0,0 +1,192 @@
+package captcha
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mojocn/base64Captcha"
+)
+
+// 工作量证明验证码选项参数
+type PoWOption struct {
+	Secret        string        //签名密钥,多实例部署时须相同,默认进程启动时随机生成
+	Difficulty    int           //基础难度(哈希前导零位数),默认18
+	MaxDifficulty int           //最大难度,默认26
+	Window        time.Duration //自适应难度的统计窗口,默认1分钟
+	Step          int           //同一客户端在窗口内每请求多少次难度加一,默认10
+}
+
+// PoWChallenge 工作量证明挑战
+//
+// 客户端需找到 nonce (10进制数字字串), 使 SHA-256(Prefix + nonce) 的前 Difficulty 位均为 0,
+// 再以 Id 与 nonce 调用 Verify/Check 校验; Go 客户端可使用 SolvePoW
+type PoWChallenge struct {
+	Id         string    `json:"id"`         //验证码ID 校验时要用
+	Challenge  string    `json:"challenge"`  //签名的挑战字串, 格式为 "Prefix.Difficulty.过期毫秒时间戳.签名"
+	Prefix     string    `json:"prefix"`     //随机前缀
+	Difficulty int       `json:"difficulty"` //难度(前导零位数)
+	ExpireAt   time.Time `json:"expireAt"`   //过期时间
+}
+
+// 工作量证明验证码, 无需用户交互, 适用于无界面的接口
+//   - {opt} 可选项参数
+//
+// Make 返回的 {b64Str} 为挑战字串 (格式见 PoWChallenge.Challenge), 需按客户端区分难度时使用 MakePoW;
+// 校验通过后即失效, 不可重放
+func WithTypePoW(opt ...*PoWOption) CaptchaType {
+	return func(c *Captcha) {
+		var o PoWOption
+		if len(opt) != 0 && opt[0] != nil {
+			o = *opt[0]
+		}
+		if o.Secret == "" {
+			b := make([]byte, 32)
+			_, _ = rand.Read(b)
+			o.Secret = string(b)
+		}
+		if o.Difficulty <= 0 {
+			o.Difficulty = 18
+		}
+		if o.MaxDifficulty < o.Difficulty {
+			o.MaxDifficulty = max(26, o.Difficulty)
+		}
+		if o.Window <= 0 {
+			o.Window = time.Minute
+		}
+		if o.Step <= 0 {
+			o.Step = 10
+		}
+		d := &powDriver{opt: o}
+		c.resetType("pow")
+		c.pow = d
+		c.match = d.matchCode
+	}
+}
+
+// MakePoW 生成工作量证明挑战
+//   - {clientKey} 客户端标识, 如 IP 或用户名, 窗口内请求越多难度越高; 为空时使用基础难度
+func (c *Captcha) MakePoW(clientKey string) (*PoWChallenge, error) {
+	return c.MakePoWContext(context.Background(), clientKey)
+}
+
+// MakePoWContext 同 MakePoW, 可传入上下文用于存储操作
+func (c *Captcha) MakePoWContext(ctx context.Context, clientKey string) (*PoWChallenge, error) {
+	if c.pow == nil {
+		return nil, ErrUnsupported
+	}
+	difficulty, err := c.pow.difficulty(ctx, c.store, clientKey)
+	if err != nil {
+		return nil, err
+	}
+	ch := c.pow.challenge(difficulty, time.Now().Add(c.expiration))
+	ch.Id = base64Captcha.RandomId()
+	if ch.Id, err = c.save(ctx, ch.Id, ch.Challenge); err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// SolvePoW 求解工作量证明挑战, 返回 nonce
+//   - {prefix} 随机前缀
+//   - {difficulty} 难度(前导零位数)
+func SolvePoW(prefix string, difficulty int) string {
+	buf := make([]byte, 0, len(prefix)+20)
+	for nonce := uint64(0); ; nonce++ {
+		buf = strconv.AppendUint(append(buf[:0], prefix...), nonce, 10)
+		sum := sha256.Sum256(buf)
+		if leadingZeroBits(sum[:]) >= difficulty {
+			return strconv.FormatUint(nonce, 10)
+		}
+	}
+}
+
+// ============================================================
+
+type powDriver struct {
+	opt PoWOption
+}
+
+// challenge 生成签名的挑战
+func (d *powDriver) challenge(difficulty int, expireAt time.Time) *PoWChallenge {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	prefix := hex.EncodeToString(b)
+	payload := prefix + "." + strconv.Itoa(difficulty) + "." + strconv.FormatInt(expireAt.UnixMilli(), 10)
+	return &PoWChallenge{
+		Challenge:  payload + "." + d.sign(payload),
+		Prefix:     prefix,
+		Difficulty: difficulty,
+		ExpireAt:   expireAt,
+	}
+}
+
+func (d *powDriver) sign(payload string) string {
+	h := hmac.New(sha256.New, []byte(d.opt.Secret))
+	h.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+// matchCode 校验挑战签名与 nonce, {answer} 为挑战字串
+//
+// 签名无效 (如多实例的 Secret 不同) 或格式错误时返回包装了 ErrMismatch 的错误
+func (d *powDriver) matchCode(answer, code string) error {
+	i := strings.LastIndexByte(answer, '.')
+	if i < 0 || !hmac.Equal([]byte(d.sign(answer[:i])), []byte(answer[i+1:])) {
+		return fmt.Errorf("%w: 挑战签名无效", ErrMismatch)
+	}
+	parts := strings.Split(answer[:i], ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("%w: 挑战格式错误", ErrMismatch)
+	}
+	difficulty, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return fmt.Errorf("%w: 挑战格式错误: %v", ErrMismatch, err)
+	}
+	if expireMs, err := strconv.ParseInt(parts[2], 10, 64); err != nil || time.Now().UnixMilli() >= expireMs {
+		return ErrExpired
+	}
+	if _, err = strconv.ParseUint(code, 10, 64); err != nil {
+		return ErrMismatch
+	}
+	sum := sha256.Sum256([]byte(parts[0] + code))
+	if leadingZeroBits(sum[:]) < difficulty {
+		return ErrMismatch
+	}
+	return nil
+}
+
+// difficulty 自适应难度, 按客户端在窗口内的请求次数递增
+//
+// 计数保存在验证码存储中 (键为 "pow:"+clientKey), 多实例共享; 使用 Store.Incr 原子计数,
+// 窗口自首次请求起算; 验证码记录使用独立的键前缀, 校验时无法以验证码ID 删除计数
+func (d *powDriver) difficulty(ctx context.Context, store Store, clientKey string) (int, error) {
+	if clientKey == "" {
+		return d.opt.Difficulty, nil
+	}
+	count, err := store.Incr(ctx, "pow:"+clientKey, d.opt.Window)
+	if err != nil {
+		return 0, err
+	}
+	return int(min(int64(d.opt.Difficulty)+(count-1)/int64(d.opt.Step), int64(d.opt.MaxDifficulty))), nil
+}
+
+// leadingZeroBits 前导零位数
+func leadingZeroBits(b []byte) int {
+	n := 0
+	for _, v := range b {
+		if v != 0 {
+			return n + bits.LeadingZeros8(v)
+		}
+		n += 8
+	}
+	return n
+}
@@ -0,0 +1,77 @@
+package captcha_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ackcoder/go-mods/captcha"
+)
+
+func TestPoW(t *testing.T) {
+	ins := captcha.New(0, 60, captcha.WithTypePoW(&captcha.PoWOption{
+		Secret:        "secret",
+		Difficulty:    8,
+		MaxDifficulty: 10,
+		Step:          2,
+	}))
+
+	// 与 Make/Check 流程一致
+	id, challenge, err := ins.Make(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(id, challenge)
+	parts := strings.Split(challenge, ".")
+	nonce := captcha.SolvePoW(parts[0], 8)
+	if !ins.Check(id, nonce) {
+		t.Error("理应校验通过")
+	}
+	if _, err = ins.Verify(id, nonce); !errors.Is(err, captcha.ErrNotFound) {
+		t.Error("重放理应不通过", err)
+	}
+
+	// 自适应难度
+	var last *captcha.PoWChallenge
+	for i := 0; i < 8; i++ {
+		if last, err = ins.MakePoW("1.2.3.4"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if last.Difficulty != 10 {
+		t.Error("难度未按请求次数递增", last.Difficulty)
+	}
+	if other, _ := ins.MakePoW("5.6.7.8"); other.Difficulty != 8 {
+		t.Error("其他客户端难度理应为基础难度", other.Difficulty)
+	}
+
+	if _, err = ins.Verify(last.Id, "abc"); !errors.Is(err, captcha.ErrMismatch) {
+		t.Error("错误 nonce 理应不通过")
+	}
+	last, _ = ins.MakePoW("")
+	if res, err := ins.Verify(last.Id, captcha.SolvePoW(last.Prefix, last.Difficulty)); err != nil || !res.OK {
+		t.Error("理应校验通过", res, err)
+	}
+}
+
+func TestPoWSignature(t *testing.T) {
+	store := captcha.NewMemoryStore()
+	a := captcha.New(0, 60, captcha.WithStore(store), captcha.WithTypePoW(&captcha.PoWOption{Secret: "a", Difficulty: 4}))
+	b := captcha.New(0, 60, captcha.WithStore(store), captcha.WithTypePoW(&captcha.PoWOption{Secret: "b", Difficulty: 4}))
+	ch, _ := a.MakePoW("")
+	if _, err := b.Verify(ch.Id, captcha.SolvePoW(ch.Prefix, ch.Difficulty)); !errors.Is(err, captcha.ErrMismatch) {
+		t.Error("签名无效理应返回 ErrMismatch", err)
+	}
+}
+
+func TestPoWCounterKey(t *testing.T) {
+	ins := captcha.New(0, 60, captcha.WithTypePoW(&captcha.PoWOption{Secret: "s", Difficulty: 4, MaxDifficulty: 20, Step: 1}))
+	for i := 0; i < 4; i++ {
+		ins.MakePoW("1.2.3.4")
+	}
+	// 以难度计数的键作为验证码ID 提交, 不应删除计数
+	ins.Verify("pow:1.2.3.4", "1")
+	if ch, _ := ins.MakePoW("1.2.3.4"); ch.Difficulty != 8 {
+		t.Error("难度计数不应被验证码校验删除", ch.Difficulty)
+	}
+}
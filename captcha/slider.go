@@ -0,0 +1,264 @@
+package captcha
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"math/rand/v2"
+	"strconv"
+
+	"github.com/mojocn/base64Captcha"
+)
+
+// 滑块验证码选项参数
+type SliderOption struct {
+	Backgrounds []image.Image //背景图池,随机选用并缩放至宽高,为空时随机生成
+	PieceSize   int           //拼图块边长(不含凸起),默认高度的1/4,最小20
+	Tolerance   int           //允许误差像素,默认5
+	SkipTrack   bool          //是否允许不提交拖动轨迹,默认否(必须提交并校验轨迹)
+}
+
+// SliderChallenge 滑块验证码
+type SliderChallenge struct {
+	Id         string `json:"id"`         //验证码ID 校验时要用
+	Background string `json:"background"` //带缺口的背景图 base64 字串 (png)
+	Piece      string `json:"piece"`      //拼图块 base64 字串 (png, 缺口外透明), 宽高均为 PieceSize
+	PieceSize  int    `json:"pieceSize"`  //拼图块图片边长(含凸起)
+	Y          int    `json:"y"`          //拼图块纵向位置, 横向从0开始拖动
+	Width      int    `json:"width"`      //背景图宽
+	Height     int    `json:"height"`     //背景图高
+}
+
+// TrackPoint 拖动轨迹点
+type TrackPoint struct {
+	X int   `json:"x"` //拼图块横向位置
+	Y int   `json:"y"` //指针纵向位置
+	T int64 `json:"t"` //距开始拖动的毫秒数
+}
+
+// 滑块验证码
+//   - {w},{h} 宽高
+//   - {opt} 可选项参数
+//
+// 注: 需使用 MakeSlider 生成, VerifySlider 校验; 也可用 Verify 校验, {code} 为横向位置数值,
+// 此时无轨迹可校验, 仅设置了 SkipTrack 时才会通过; 尺寸过小时 MakeSlider 返回错误
+func WithTypeSlider(w, h int, opt ...*SliderOption) CaptchaType {
+	return func(c *Captcha) {
+		var o SliderOption
+		if len(opt) != 0 && opt[0] != nil {
+			o = *opt[0]
+		}
+		if o.PieceSize <= 0 {
+			o.PieceSize = max(h/4, 20)
+		}
+		if o.Tolerance <= 0 {
+			o.Tolerance = 5
+		}
+		d := &sliderDriver{width: w, height: h, opt: o}
+		// 拼图块需能完整放入背景图, 且初始位置与缺口不重叠
+		for d.boxSize() > min(h-10, (w-15)/2) && d.opt.PieceSize > 10 {
+			d.opt.PieceSize--
+		}
+		d.mask = d.buildMask()
+		c.resetType("slider")
+		if d.boxSize() > min(h-10, (w-15)/2) {
+			c.err = fmt.Errorf("captcha: 滑块验证码尺寸 %dx%d 过小", w, h)
+		}
+		c.slider = d
+		c.match = func(answer, code string) error {
+			if err := d.matchCode(answer, code); err != nil {
+				return err
+			}
+			return d.checkTrack(0, nil)
+		}
+	}
+}
+
+// MakeSlider 生成滑块验证码
+//   - {withFormatPrefix} 图片是否携带格式前缀
+func (c *Captcha) MakeSlider(withFormatPrefix bool) (*SliderChallenge, error) {
+	return c.MakeSliderContext(context.Background(), withFormatPrefix)
+}
+
+// MakeSliderContext 同 MakeSlider, 可传入上下文用于存储操作
+func (c *Captcha) MakeSliderContext(ctx context.Context, withFormatPrefix bool) (*SliderChallenge, error) {
+	if c.slider == nil {
+		return nil, ErrUnsupported
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	ch, x, err := c.slider.draw(withFormatPrefix)
+	if err != nil {
+		return nil, err
+	}
+	ch.Id = base64Captcha.RandomId()
+	if ch.Id, err = c.save(ctx, ch.Id, strconv.Itoa(x)); err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// VerifySlider 校验滑块验证码
+//   - {idKey} 验证码ID
+//   - {x} 拼图块最终横向位置
+//   - {track} 拖动轨迹, 必须提交 (SliderOption.SkipTrack 为真时可为空, 为空时不校验)
+//
+// 轨迹异常时返回包装了 ErrMismatch 的错误
+func (c *Captcha) VerifySlider(idKey string, x int, track []TrackPoint) (Result, error) {
+	return c.VerifySliderContext(context.Background(), idKey, x, track)
+}
+
+// VerifySliderContext 同 VerifySlider, 可传入上下文用于存储操作
+func (c *Captcha) VerifySliderContext(ctx context.Context, idKey string, x int, track []TrackPoint) (Result, error) {
+	if c.slider == nil {
+		return Result{}, ErrUnsupported
+	}
+	return c.verify(ctx, idKey, func(answer string) error {
+		if err := c.slider.matchCode(answer, strconv.Itoa(x)); err != nil {
+			return err
+		}
+		return c.slider.checkTrack(x, track)
+	})
+}
+
+// ============================================================
+
+type sliderDriver struct {
+	width, height int
+	opt           SliderOption
+	mask          []bool //拼图块形状, boxSize*boxSize
+}
+
+// knobRadius 凸起半径
+func (d *sliderDriver) knobRadius() int {
+	return max(d.opt.PieceSize/5, 3)
+}
+
+// boxSize 拼图块图片边长 (含上方与右侧凸起)
+func (d *sliderDriver) boxSize() int {
+	return d.opt.PieceSize + d.knobRadius()
+}
+
+// buildMask 拼图块形状: 正方形 + 上方与右侧半圆凸起
+func (d *sliderDriver) buildMask() []bool {
+	s, r, n := d.opt.PieceSize, d.knobRadius(), d.boxSize()
+	inCircle := func(x, y, cx, cy int) bool {
+		return (x-cx)*(x-cx)+(y-cy)*(y-cy) <= r*r
+	}
+	mask := make([]bool, n*n)
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			mask[y*n+x] = (x < s && y >= r && y < r+s) ||
+				inCircle(x, y, s/2, r) ||
+				inCircle(x, y, s, r+s/2)
+		}
+	}
+	return mask
+}
+
+func (d *sliderDriver) inMask(x, y int) bool {
+	n := d.boxSize()
+	return x >= 0 && y >= 0 && x < n && y < n && d.mask[y*n+x]
+}
+
+// isEdge 是否为形状边缘
+func (d *sliderDriver) isEdge(x, y int) bool {
+	return !d.inMask(x-1, y) || !d.inMask(x+1, y) || !d.inMask(x, y-1) || !d.inMask(x, y+1)
+}
+
+// draw 绘制背景图与拼图块, 返回缺口横向位置
+func (d *sliderDriver) draw(withFormatPrefix bool) (*SliderChallenge, int, error) {
+	n := d.boxSize()
+	bg := drawBackground(d.width, d.height, d.opt.Backgrounds)
+	x := n + 10 + rand.IntN(d.width-2*n-14)
+	y := 5 + rand.IntN(d.height-n-9)
+
+	piece := image.NewRGBA(image.Rect(0, 0, n, n))
+	for py := 0; py < n; py++ {
+		for px := 0; px < n; px++ {
+			if !d.inMask(px, py) {
+				continue
+			}
+			c := bg.RGBAAt(x+px, y+py)
+			if d.isEdge(px, py) {
+				piece.SetRGBA(px, py, color.RGBA{255, 255, 255, 230})
+				bg.SetRGBA(x+px, y+py, blend(c, color.RGBA{255, 255, 255, 255}, 0.6))
+				continue
+			}
+			piece.SetRGBA(px, py, c)
+			bg.SetRGBA(x+px, y+py, blend(c, color.RGBA{0, 0, 0, 255}, 0.55))
+		}
+	}
+
+	ch := &SliderChallenge{PieceSize: n, Y: y, Width: d.width, Height: d.height}
+	var err error
+	if ch.Background, err = encodePNG(bg, withFormatPrefix); err != nil {
+		return nil, 0, err
+	}
+	if ch.Piece, err = encodePNG(piece, withFormatPrefix); err != nil {
+		return nil, 0, err
+	}
+	return ch, x, nil
+}
+
+// matchCode 比对横向位置, 允许 Tolerance 像素误差
+func (d *sliderDriver) matchCode(answer, code string) error {
+	want, err := strconv.Atoi(answer)
+	if err != nil {
+		return fmt.Errorf("captcha: 记录格式错误: %w", err)
+	}
+	got, err := strconv.Atoi(code)
+	if err != nil || abs(got-want) > d.opt.Tolerance {
+		return ErrMismatch
+	}
+	return nil
+}
+
+// checkTrack 轨迹合理性检查: 点数, 耗时, 终点位置, 非匀速
+func (d *sliderDriver) checkTrack(x int, track []TrackPoint) error {
+	if len(track) == 0 {
+		if d.opt.SkipTrack {
+			return nil
+		}
+		return fmt.Errorf("%w: 缺少拖动轨迹", ErrMismatch)
+	}
+	if len(track) < 5 {
+		return fmt.Errorf("%w: 轨迹点过少", ErrMismatch)
+	}
+	last := track[len(track)-1]
+	if abs(last.X-x) > d.opt.Tolerance {
+		return fmt.Errorf("%w: 轨迹终点与提交位置不符", ErrMismatch)
+	}
+	if last.T-track[0].T < 150 {
+		return fmt.Errorf("%w: 拖动过快", ErrMismatch)
+	}
+
+	var speeds []float64
+	for i := 1; i < len(track); i++ {
+		dt := track[i].T - track[i-1].T
+		if dt < 0 {
+			return fmt.Errorf("%w: 轨迹时间错误", ErrMismatch)
+		}
+		if dt > 0 {
+			speeds = append(speeds, float64(track[i].X-track[i-1].X)/float64(dt))
+		}
+	}
+	// 速度变异系数过小视为匀速 (人工拖动通常先加速后减速)
+	if len(speeds) >= 3 {
+		var sum, sq float64
+		for _, v := range speeds {
+			sum += v
+		}
+		mean := sum / float64(len(speeds))
+		for _, v := range speeds {
+			sq += (v - mean) * (v - mean)
+		}
+		if mean <= 0 || math.Sqrt(sq/float64(len(speeds)))/mean < 0.15 {
+			return fmt.Errorf("%w: 匀速轨迹", ErrMismatch)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package captcha_test
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"strconv"
+	"testing"
+
+	"github.com/ackcoder/go-mods/captcha"
+)
+
+// humanTrack 模拟先加速后减速的拖动轨迹
+func humanTrack(x int) []captcha.TrackPoint {
+	track := []captcha.TrackPoint{{X: 0, Y: 10, T: 0}}
+	for i := 1; i <= 10; i++ {
+		p := float64(i) / 10
+		track = append(track, captcha.TrackPoint{
+			X: int(float64(x) * p * (2 - p)),
+			Y: 10 + i%3,
+			T: int64(i * 40),
+		})
+	}
+	return track
+}
+
+func TestSlider(t *testing.T) {
+	store := captcha.NewMemoryStore()
+	bg := image.NewRGBA(image.Rect(0, 0, 600, 300))
+	draw.Draw(bg, bg.Bounds(), &image.Uniform{color.RGBA{80, 160, 200, 255}}, image.Point{}, draw.Src)
+	ins := captcha.New(0, 60, captcha.WithStore(store), captcha.WithKeepOnFailure(),
+		captcha.WithTypeSlider(300, 150, &captcha.SliderOption{Backgrounds: []image.Image{bg}}),
+	)
+
+	if _, _, err := ins.Make(false); !errors.Is(err, captcha.ErrUnsupported) {
+		t.Error("滑块验证码 Make 理应返回 ErrUnsupported", err)
+	}
+
+	ch, err := ins.MakeSlider(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(ch.Id, ch.Y, ch.PieceSize, len(ch.Background), len(ch.Piece))
+	x, _ := strconv.Atoi(answerOf(t, store, ch.Id))
+
+	if _, err = ins.VerifySlider(ch.Id, x+20, nil); !errors.Is(err, captcha.ErrMismatch) {
+		t.Error("位置错误理应不通过", err)
+	}
+	robot := []captcha.TrackPoint{}
+	for i := 0; i <= 10; i++ {
+		robot = append(robot, captcha.TrackPoint{X: x * i / 10, T: int64(i * 40)})
+	}
+	if _, err = ins.VerifySlider(ch.Id, x, robot); !errors.Is(err, captcha.ErrMismatch) {
+		t.Error("匀速轨迹理应不通过", err)
+	}
+	if res, err := ins.VerifySlider(ch.Id, x+3, humanTrack(x+3)); err != nil || !res.OK {
+		t.Error("理应校验通过", res, err)
+	}
+
+	// 默认必须提交轨迹
+	ch, _ = ins.MakeSlider(false)
+	x, _ = strconv.Atoi(answerOf(t, store, ch.Id))
+	if _, err = ins.VerifySlider(ch.Id, x, nil); !errors.Is(err, captcha.ErrMismatch) {
+		t.Error("缺少轨迹理应不通过", err)
+	}
+	if ins.Check(ch.Id, strconv.Itoa(x)) {
+		t.Error("Verify 无轨迹理应不通过")
+	}
+}
+
+func TestSliderSkipTrack(t *testing.T) {
+	store := captcha.NewMemoryStore()
+	ins := captcha.New(0, 60, captcha.WithStore(store), captcha.WithTypeSlider(320, 160, &captcha.SliderOption{SkipTrack: true}))
+	ch, err := ins.MakeSlider(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 不带轨迹时 Verify 也可使用
+	if !ins.Check(ch.Id, answerOf(t, store, ch.Id)) {
+		t.Error("理应校验通过")
+	}
+}
+
+func TestSliderTooSmall(t *testing.T) {
+	ins := captcha.New(0, 60, captcha.WithTypeSlider(40, 20))
+	if _, err := ins.MakeSlider(false); err == nil {
+		t.Error("尺寸过小理应返回错误")
+	}
+}
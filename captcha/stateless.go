@@ -0,0 +1,174 @@
+package captcha
+
+import (
+	"container/list"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ackcoder/go-mods/utils"
+)
+
+// ErrReplayCacheFull 无状态模式防重放缓存已满 (均未过期), 为防止重放拒绝校验
+var ErrReplayCacheFull = errors.New("captcha: replay cache full")
+
+// WithStateless 无状态令牌模式, 服务端无需存储验证码
+//   - {secret} 密钥, 多实例部署时须相同
+//   - {replayCacheSize} 可选, 防重放缓存容量, 默认10000, 应不小于有效期内的校验次数
+//
+// 生成时验证码ID 为加密并签名的令牌 (内含过期时间与答案, 文字类验证码仅含答案哈希),
+// 校验时解密令牌比对答案, 不读写 Store
+//
+//	注: 令牌使用一次即失效 (无论是否通过, WithMaxAttempts/WithKeepOnFailure 不生效),
+//	防重放缓存仅在当前进程内有效, 多实例部署时同一令牌可能在不同实例各使用一次;
+//	缓存只淘汰已过期的令牌, 已满时校验返回 ErrReplayCacheFull
+func WithStateless(secret string, replayCacheSize ...int) CaptchaType {
+	return func(c *Captcha) {
+		size := 10000
+		if len(replayCacheSize) != 0 && replayCacheSize[0] > 0 {
+			size = replayCacheSize[0]
+		}
+		encKey := sha256.Sum256([]byte("captcha:enc:" + secret))
+		block, _ := aes.NewCipher(encKey[:])
+		c.stateless = &stateless{
+			block:  block,
+			secret: "captcha:mac:" + secret,
+			used:   newReplayCache(size),
+		}
+	}
+}
+
+// tokenIdSize 令牌内随机ID 字节数, 用于防重放
+const tokenIdSize = 12
+
+type stateless struct {
+	block  cipher.Block
+	secret string //签名密钥
+	used   *replayCache
+}
+
+// issue 生成令牌: base64url(iv + 加密(随机ID + 记录)) + "." + 签名
+//   - {hashed} 是否仅保存答案哈希
+func (s *stateless) issue(r record, hashed bool) (string, error) {
+	if hashed {
+		r.answer = s.hashAnswer(r.answer)
+	}
+	plain := make([]byte, tokenIdSize, tokenIdSize+64)
+	if _, err := rand.Read(plain); err != nil {
+		return "", err
+	}
+	plain = append(plain, r.encode()...)
+
+	buf := make([]byte, aes.BlockSize+len(plain))
+	iv := buf[:aes.BlockSize]
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+	cipher.NewCTR(s.block, iv).XORKeyStream(buf[aes.BlockSize:], plain)
+	payload := base64.RawURLEncoding.EncodeToString(buf)
+	return payload + "." + utils.Sign.HmacSha256Hex(payload, s.secret), nil
+}
+
+// open 校验签名并解密令牌
+func (s *stateless) open(token string) (id string, r record, ok bool) {
+	payload, sig, found := strings.Cut(token, ".")
+	if !found || !hmac.Equal([]byte(sig), []byte(utils.Sign.HmacSha256Hex(payload, s.secret))) {
+		return
+	}
+	buf, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil || len(buf) < aes.BlockSize+tokenIdSize {
+		return
+	}
+	plain := buf[aes.BlockSize:]
+	cipher.NewCTR(s.block, buf[:aes.BlockSize]).XORKeyStream(plain, plain)
+	if r, err = decodeRecord(string(plain[tokenIdSize:])); err != nil {
+		return
+	}
+	return string(plain[:tokenIdSize]), r, true
+}
+
+// verify 校验令牌, {typ} 为验证码类型名, 与令牌中的不符时返回 ErrNotFound
+func (s *stateless) verify(token, typ string, match func(answer string) error) (Result, error) {
+	id, r, ok := s.open(token)
+	if !ok || r.typ != typ {
+		return Result{}, ErrNotFound
+	}
+	if !time.Now().Before(r.expireAt) {
+		return Result{}, ErrExpired
+	}
+	if err := s.used.add(id, r.expireAt); err != nil {
+		return Result{}, err
+	}
+	res := Result{Attempts: 1}
+	if err := match(r.answer); err != nil {
+		return res, err
+	}
+	res.OK = true
+	return res, nil
+}
+
+// hashAnswer 文字答案哈希 (不区分大小写)
+func (s *stateless) hashAnswer(answer string) string {
+	return utils.Sign.HmacSha256Hex(strings.ToLower(answer), s.secret)[:32]
+}
+
+// ============================================================
+
+// replayCache 已使用令牌缓存, 仅淘汰已过期的令牌
+//
+// 未过期的令牌被淘汰后即可重放, 因此容量满且均未过期时拒绝加入
+type replayCache struct {
+	mu    sync.Mutex
+	size  int
+	items map[string]*list.Element
+	order *list.List
+}
+
+type replayItem struct {
+	id       string
+	expireAt time.Time
+}
+
+func newReplayCache(size int) *replayCache {
+	return &replayCache{size: size, items: make(map[string]*list.Element), order: list.New()}
+}
+
+// add 记录已使用, 已存在时返回 ErrNotFound, 容量已满时返回 ErrReplayCacheFull
+func (c *replayCache) add(id string, expireAt time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.items[id]; ok {
+		return ErrNotFound
+	}
+	now := time.Now()
+	// 按加入顺序淘汰队首的过期令牌, 容量仍满时再完整扫描一次
+	for e := c.order.Front(); e != nil && !now.Before(e.Value.(replayItem).expireAt); e = c.order.Front() {
+		c.remove(e)
+	}
+	if c.order.Len() >= c.size {
+		for e := c.order.Front(); e != nil; {
+			next := e.Next()
+			if !now.Before(e.Value.(replayItem).expireAt) {
+				c.remove(e)
+			}
+			e = next
+		}
+		if c.order.Len() >= c.size {
+			return ErrReplayCacheFull
+		}
+	}
+	c.items[id] = c.order.PushBack(replayItem{id: id, expireAt: expireAt})
+	return nil
+}
+
+func (c *replayCache) remove(e *list.Element) {
+	c.order.Remove(e)
+	delete(c.items, e.Value.(replayItem).id)
+}
@@ -0,0 +1,95 @@
+package captcha_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ackcoder/go-mods/captcha"
+)
+
+func TestStateless(t *testing.T) {
+	// 取值源只有一个字符, 答案固定为 "AAAA"
+	opt := &captcha.ImageOption{Source: "A"}
+	ins := captcha.New(4, 60, captcha.WithTypeString(120, 40, opt), captcha.WithStateless("secret"))
+
+	id, _, err := ins.Make(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(len(id), id)
+	if strings.Contains(id, "AAAA") {
+		t.Error("令牌不应含明文答案")
+	}
+
+	// 其他实例 (相同密钥) 可校验
+	other := captcha.New(4, 60, captcha.WithTypeString(120, 40, opt), captcha.WithStateless("secret"))
+	if res, err := other.Verify(id, "aaaa"); err != nil || !res.OK {
+		t.Error("理应校验通过", res, err)
+	}
+	if _, err = other.Verify(id, "AAAA"); !errors.Is(err, captcha.ErrNotFound) {
+		t.Error("重放理应不通过", err)
+	}
+
+	id, _, _ = ins.Make(false)
+	if _, err = ins.Verify(id, "BBBB"); !errors.Is(err, captcha.ErrMismatch) {
+		t.Error("理应返回 ErrMismatch", err)
+	}
+	if ins.Check(id, "AAAA") {
+		t.Error("令牌理应只能使用一次")
+	}
+
+	id, _, _ = ins.Make(false)
+	tampered := []byte(id)
+	tampered[3] ^= 1
+	if ins.Check(string(tampered), "AAAA") {
+		t.Error("篡改的令牌理应不通过")
+	}
+	if !ins.Check(id, "AAAA") {
+		t.Error("原令牌理应通过")
+	}
+	wrongKey := captcha.New(4, 60, captcha.WithTypeString(120, 40, opt), captcha.WithStateless("other"))
+	id, _, _ = ins.Make(false)
+	if wrongKey.Check(id, "AAAA") {
+		t.Error("密钥不同理应不通过")
+	}
+
+	expired := captcha.New(4, 0, captcha.WithTypeString(120, 40, opt), captcha.WithStateless("secret"))
+	id, _, _ = expired.Make(false)
+	if _, err = expired.Verify(id, "AAAA"); !errors.Is(err, captcha.ErrExpired) {
+		t.Error("理应返回 ErrExpired", err)
+	}
+}
+
+func TestStatelessReplayCacheFull(t *testing.T) {
+	opt := &captcha.ImageOption{Source: "A"}
+	ins := captcha.New(4, 60, captcha.WithTypeString(120, 40, opt), captcha.WithStateless("secret", 100))
+
+	used, _, _ := ins.Make(false)
+	if !ins.Check(used, "AAAA") {
+		t.Fatal("理应校验通过")
+	}
+	// 大量校验填满缓存后, 已使用的令牌仍不可重放
+	for i := 0; i < 100; i++ {
+		id, _, _ := ins.Make(false)
+		ins.Check(id, "BBBB")
+	}
+	if ins.Check(used, "AAAA") {
+		t.Error("缓存填满后已使用的令牌不可重放")
+	}
+	id, _, _ := ins.Make(false)
+	if _, err := ins.Verify(id, "AAAA"); !errors.Is(err, captcha.ErrReplayCacheFull) {
+		t.Error("缓存已满理应拒绝校验", err)
+	}
+}
+
+func TestStatelessPoW(t *testing.T) {
+	ins := captcha.New(0, 60, captcha.WithTypePoW(&captcha.PoWOption{Secret: "s", Difficulty: 6}), captcha.WithStateless("secret"))
+	ch, err := ins.MakePoW("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ins.Check(ch.Id, captcha.SolvePoW(ch.Prefix, ch.Difficulty)) {
+		t.Error("理应校验通过")
+	}
+}
@@ -0,0 +1,332 @@
+package captcha
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrNotFound 验证码不存在或已过期
+var ErrNotFound = errors.New("captcha: not found")
+
+// Store 验证码存储
+//
+// 默认使用进程内存储 (NewMemoryStore), 多实例部署时应使用共享存储, 如 NewRESPStore, NewSQLStore
+type Store interface {
+	// Set 保存, {ttl} 后过期
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	// Get 读取, 不存在或已过期时返回 ErrNotFound
+	Get(ctx context.Context, key string) (string, error)
+	// Take 读取并删除 (须为原子操作), 不存在或已过期时返回 ErrNotFound
+	//
+	// 校验时使用, 保证同一验证码并发提交时只有一个请求能取得记录
+	Take(ctx context.Context, key string) (string, error)
+	// Incr 计数加一并返回新值 (须为原子操作), 不存在或已过期时从1开始计数并在 {ttl} 后过期
+	//
+	// 风控 (Guard) 失败计数, 工作量证明自适应难度计数时使用; 计数加一不改变过期时间
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// Delete 删除, 不存在时不报错
+	Delete(ctx context.Context, key string) error
+}
+
+// WithStore 设置验证码存储
+//   - {store} 存储实现
+func WithStore(store Store) CaptchaType {
+	return func(c *Captcha) {
+		c.store = store
+	}
+}
+
+// ============================================================
+
+// MemoryStore 进程内存储
+type MemoryStore struct {
+	mu      sync.Mutex
+	items   map[string]memoryItem
+	writes  int
+	gcEvery int
+}
+
+type memoryItem struct {
+	value    string
+	expireAt time.Time
+}
+
+// NewMemoryStore 创建进程内存储
+//   - {gcEvery} 可选, 每写入多少次清理一次过期项, 默认 1000 (与 base64Captcha.GCLimitNumber 一致)
+func NewMemoryStore(gcEvery ...int) *MemoryStore {
+	s := &MemoryStore{items: make(map[string]memoryItem), gcEvery: 1000}
+	if len(gcEvery) != 0 && gcEvery[0] > 0 {
+		s.gcEvery = gcEvery[0]
+	}
+	return s
+}
+
+func (s *MemoryStore) Set(_ context.Context, key, value string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items[key] = memoryItem{value: value, expireAt: time.Now().Add(ttl)}
+	if s.writes++; s.writes >= s.gcEvery {
+		s.writes = 0
+		s.cleanup()
+	}
+	return nil
+}
+
+func (s *MemoryStore) Get(_ context.Context, key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.items[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	if !time.Now().Before(item.expireAt) {
+		delete(s.items, key)
+		return "", ErrNotFound
+	}
+	return item.value, nil
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.items[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	delete(s.items, key)
+	if !time.Now().Before(item.expireAt) {
+		return "", ErrNotFound
+	}
+	return item.value, nil
+}
+
+func (s *MemoryStore) Incr(_ context.Context, key string, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.items[key]
+	if !ok || !time.Now().Before(item.expireAt) {
+		s.items[key] = memoryItem{value: "1", expireAt: time.Now().Add(ttl)}
+		return 1, nil
+	}
+	n, err := strconv.ParseInt(item.value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("captcha: 计数格式错误: %w", err)
+	}
+	n++
+	item.value = strconv.FormatInt(n, 10)
+	s.items[key] = item
+	return n, nil
+}
+
+func (s *MemoryStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.items, key)
+	return nil
+}
+
+// Len 当前存储项数 (含未清理的过期项)
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.items)
+}
+
+// cleanup 清理过期项, 需持有锁
+func (s *MemoryStore) cleanup() {
+	now := time.Now()
+	for k, item := range s.items {
+		if !now.Before(item.expireAt) {
+			delete(s.items, k)
+		}
+	}
+}
+
+// ============================================================
+
+// SQLStore 数据库存储
+//
+// 表结构参考 (MySQL):
+//
+//	CREATE TABLE captcha (
+//	  id        VARCHAR(64)  NOT NULL PRIMARY KEY,
+//	  value     VARCHAR(512) NOT NULL,
+//	  expire_at BIGINT       NOT NULL, -- 过期时间, 毫秒时间戳
+//	  KEY idx_expire_at (expire_at)
+//	);
+//
+//	注: 过期数据不会自动删除, 需定期调用 Cleanup 或使用 StartCleanup
+type SQLStore struct {
+	db       *sql.DB
+	del      string
+	delValue string
+	insert   string
+	query    string
+	swap     string
+	cleanup  string
+}
+
+// NewSQLStore 创建数据库存储
+//   - {db} 数据库连接
+//   - {table} 表名, 如 "captcha"
+//   - {dollarPlaceholder} 可选, 是否使用 $1 占位符 (PostgreSQL), 默认使用 ? 占位符
+func NewSQLStore(db *sql.DB, table string, dollarPlaceholder ...bool) *SQLStore {
+	ph := []string{"?", "?", "?", "?", "?"}
+	if len(dollarPlaceholder) != 0 && dollarPlaceholder[0] {
+		ph = []string{"$1", "$2", "$3", "$4", "$5"}
+	}
+	return &SQLStore{
+		db:       db,
+		del:      fmt.Sprintf("DELETE FROM %s WHERE id = %s", table, ph[0]),
+		delValue: fmt.Sprintf("DELETE FROM %s WHERE id = %s AND value = %s", table, ph[0], ph[1]),
+		insert:   fmt.Sprintf("INSERT INTO %s (id, value, expire_at) VALUES (%s, %s, %s)", table, ph[0], ph[1], ph[2]),
+		query:    fmt.Sprintf("SELECT value, expire_at FROM %s WHERE id = %s", table, ph[0]),
+		swap:     fmt.Sprintf("UPDATE %s SET value = %s, expire_at = %s WHERE id = %s AND value = %s AND expire_at = %s", table, ph[0], ph[1], ph[2], ph[3], ph[4]),
+		cleanup:  fmt.Sprintf("DELETE FROM %s WHERE expire_at <= %s", table, ph[0]),
+	}
+}
+
+// Set 在事务中先删除再插入, 兼容各数据库的写入语法
+func (s *SQLStore) Set(ctx context.Context, key, value string, ttl time.Duration) (err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	if _, err = tx.ExecContext(ctx, s.del, key); err != nil {
+		return
+	}
+	if _, err = tx.ExecContext(ctx, s.insert, key, value, time.Now().Add(ttl).UnixMilli()); err != nil {
+		return
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) Get(ctx context.Context, key string) (string, error) {
+	var (
+		value    string
+		expireAt int64
+	)
+	err := s.db.QueryRowContext(ctx, s.query, key).Scan(&value, &expireAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	if expireAt <= time.Now().UnixMilli() {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+// Take 读取后按键与值删除, 仅删除成功 (影响1行) 的请求取得记录, 兼容各数据库
+//
+// 并发读取到同一记录时只有一个请求删除成功; 按值删除可避免删掉读取后被重新写入的新记录
+func (s *SQLStore) Take(ctx context.Context, key string) (string, error) {
+	value, err := s.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	res, err := s.db.ExecContext(ctx, s.delValue, key, value)
+	if err != nil {
+		return "", err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return "", err
+	} else if n == 0 {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+// Incr 读取后按原值条件更新 (比较并交换), 不存在时插入, 冲突时重试, 兼容各数据库
+//
+// 每次冲突都意味着其他请求已更新成功, 因此重试次数不超过并发请求数
+func (s *SQLStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	var insertErr error //插入失败且记录仍不存在时, 视为数据库错误而非并发插入冲突
+	for {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		var (
+			value    string
+			expireAt int64
+		)
+		err := s.db.QueryRowContext(ctx, s.query, key).Scan(&value, &expireAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			if insertErr != nil {
+				return 0, insertErr
+			}
+			// 并发插入时主键冲突, 重试后读取到对方插入的记录
+			if _, insertErr = s.db.ExecContext(ctx, s.insert, key, "1", time.Now().Add(ttl).UnixMilli()); insertErr == nil {
+				return 1, nil
+			}
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		var n int64
+		newExpireAt := expireAt
+		if now := time.Now(); expireAt <= now.UnixMilli() {
+			n, newExpireAt = 1, now.Add(ttl).UnixMilli()
+		} else if n, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return 0, fmt.Errorf("captcha: 计数格式错误: %w", err)
+		} else {
+			n++
+		}
+		res, err := s.db.ExecContext(ctx, s.swap, strconv.FormatInt(n, 10), newExpireAt, key, value, expireAt)
+		if err != nil {
+			return 0, err
+		}
+		if rows, err := res.RowsAffected(); err != nil {
+			return 0, err
+		} else if rows == 1 {
+			return n, nil
+		}
+	}
+}
+
+func (s *SQLStore) Delete(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, s.del, key)
+	return err
+}
+
+// Cleanup 删除过期数据, 返回删除条数
+func (s *SQLStore) Cleanup(ctx context.Context) (int64, error) {
+	res, err := s.db.ExecContext(ctx, s.cleanup, time.Now().UnixMilli())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// StartCleanup 后台定期删除过期数据, 返回停止函数
+//   - {interval} 清理间隔, 如 time.Minute
+func (s *SQLStore) StartCleanup(interval time.Duration) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				_, _ = s.Cleanup(ctx)
+			}
+		}
+	}()
+	return cancel
+}
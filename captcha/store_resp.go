@@ -0,0 +1,259 @@
+package captcha
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RESPOption RESP 存储选项参数
+type RESPOption struct {
+	Password string        //密码, 为空时不认证
+	DB       int           //库序号, 默认0
+	Prefix   string        //键前缀, 默认 "captcha:"
+	PoolSize int           //最大空闲连接数, 默认8
+	Timeout  time.Duration //连接与读写超时, 默认3秒 (ctx 带截止时间时以较早者为准)
+}
+
+// RESPStore RESP 协议存储, 适用于 Redis 及兼容服务 (如 KeyDB, Dragonfly, Valkey)
+//
+// 内置精简客户端, 仅使用 SET/GET/GETDEL/DEL 命令及 EVAL 脚本 (INCR/PEXPIRE), 过期由服务端处理;
+// 服务端不支持 GETDEL (Redis 6.2 以下) 时改用 EVAL 脚本原子读取并删除
+type RESPStore struct {
+	addr string
+	opt  RESPOption
+	pool chan *respConn
+}
+
+// NewRESPStore 创建 RESP 协议存储
+//   - {addr} 服务地址, 如 "127.0.0.1:6379"
+//   - {opt} 可选项参数
+func NewRESPStore(addr string, opt ...*RESPOption) *RESPStore {
+	var o RESPOption
+	if len(opt) != 0 && opt[0] != nil {
+		o = *opt[0]
+	}
+	if o.Prefix == "" {
+		o.Prefix = "captcha:"
+	}
+	if o.PoolSize <= 0 {
+		o.PoolSize = 8
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 3 * time.Second
+	}
+	return &RESPStore{addr: addr, opt: o, pool: make(chan *respConn, o.PoolSize)}
+}
+
+func (s *RESPStore) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	ms := ttl.Milliseconds()
+	if ms <= 0 {
+		ms = 1
+	}
+	_, err := s.do(ctx, "SET", s.opt.Prefix+key, value, "PX", strconv.FormatInt(ms, 10))
+	return err
+}
+
+func (s *RESPStore) Get(ctx context.Context, key string) (string, error) {
+	reply, err := s.do(ctx, "GET", s.opt.Prefix+key)
+	if err != nil {
+		return "", err
+	}
+	v, ok := reply.(string)
+	if !ok {
+		return "", ErrNotFound
+	}
+	return v, nil
+}
+
+func (s *RESPStore) Take(ctx context.Context, key string) (string, error) {
+	reply, err := s.do(ctx, "GETDEL", s.opt.Prefix+key)
+	var replyErr respError
+	if errors.As(err, &replyErr) && strings.Contains(strings.ToLower(string(replyErr)), "unknown command") {
+		reply, err = s.do(ctx, "EVAL", takeScript, "1", s.opt.Prefix+key)
+	}
+	if err != nil {
+		return "", err
+	}
+	v, ok := reply.(string)
+	if !ok {
+		return "", ErrNotFound
+	}
+	return v, nil
+}
+
+// takeScript 原子读取并删除
+const takeScript = `local v = redis.call('GET', KEYS[1]) if v then redis.call('DEL', KEYS[1]) end return v`
+
+func (s *RESPStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	ms := ttl.Milliseconds()
+	if ms <= 0 {
+		ms = 1
+	}
+	reply, err := s.do(ctx, "EVAL", incrScript, "1", s.opt.Prefix+key, strconv.FormatInt(ms, 10))
+	if err != nil {
+		return 0, err
+	}
+	n, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("captcha: 计数响应错误: %v", reply)
+	}
+	return n, nil
+}
+
+// incrScript 原子加一, 新建时设置过期时间
+const incrScript = `local n = redis.call('INCR', KEYS[1]) if n == 1 then redis.call('PEXPIRE', KEYS[1], ARGV[1]) end return n`
+
+func (s *RESPStore) Delete(ctx context.Context, key string) error {
+	_, err := s.do(ctx, "DEL", s.opt.Prefix+key)
+	return err
+}
+
+// Close 关闭空闲连接
+func (s *RESPStore) Close() error {
+	for {
+		select {
+		case c := <-s.pool:
+			c.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// do 执行命令
+//
+// 返回值: 简单字串/批量字串为 string, 整数为 int64, 空批量字串为 nil
+func (s *RESPStore) do(ctx context.Context, args ...string) (any, error) {
+	c, err := s.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := c.do(s.deadline(ctx), args...)
+	var replyErr respError
+	if err != nil && !errors.As(err, &replyErr) {
+		c.Close() //网络或协议错误, 连接不可复用
+		return nil, err
+	}
+	s.put(c)
+	return reply, err
+}
+
+func (s *RESPStore) get(ctx context.Context) (*respConn, error) {
+	select {
+	case c := <-s.pool:
+		return c, nil
+	default:
+	}
+	d := net.Dialer{Timeout: s.opt.Timeout}
+	conn, err := d.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return nil, err
+	}
+	c := &respConn{Conn: conn, r: bufio.NewReader(conn)}
+	deadline := s.deadline(ctx)
+	if s.opt.Password != "" {
+		if _, err = c.do(deadline, "AUTH", s.opt.Password); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	if s.opt.DB != 0 {
+		if _, err = c.do(deadline, "SELECT", strconv.Itoa(s.opt.DB)); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (s *RESPStore) put(c *respConn) {
+	select {
+	case s.pool <- c:
+	default:
+		c.Close()
+	}
+}
+
+func (s *RESPStore) deadline(ctx context.Context) time.Time {
+	deadline := time.Now().Add(s.opt.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	return deadline
+}
+
+// ============================================================
+
+// respError 服务端返回的错误
+type respError string
+
+func (e respError) Error() string {
+	return "captcha: resp: " + string(e)
+}
+
+// respConn RESP 连接
+type respConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *respConn) do(deadline time.Time, args ...string) (any, error) {
+	if err := c.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, arg...)
+		buf = append(buf, '\r', '\n')
+	}
+	if _, err := c.Write(buf); err != nil {
+		return nil, err
+	}
+	return c.read()
+}
+
+func (c *respConn) read() (any, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("captcha: resp: 协议错误 %q", line)
+	}
+	body := line[1 : len(line)-2]
+	switch line[0] {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, respError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("captcha: resp: 协议错误 %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		b := make([]byte, n+2)
+		if _, err = io.ReadFull(c.r, b); err != nil {
+			return nil, err
+		}
+		return string(b[:n]), nil
+	}
+	return nil, fmt.Errorf("captcha: resp: 不支持的响应类型 %q", line[0])
+}
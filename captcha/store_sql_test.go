@@ -0,0 +1,263 @@
+package captcha_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ackcoder/go-mods/captcha"
+)
+
+// captchaDriver 模拟 captcha 表的 database/sql 驱动, 仅支持 SQLStore 使用的语句
+//
+// 每条语句持有表锁执行, 事务持有表锁直至结束; 语句执行后等待 {delay}, 使并发请求交错执行
+type captchaDriver struct {
+	mu    sync.Mutex
+	rows  map[string]captchaRow
+	delay time.Duration
+}
+
+type captchaRow struct {
+	value    string
+	expireAt int64
+}
+
+var captchaDBs sync.Map
+
+func openCaptchaDB(t *testing.T, delay time.Duration) (*sql.DB, *captchaDriver) {
+	name := "captcha-" + t.Name()
+	d := &captchaDriver{rows: make(map[string]captchaRow), delay: delay}
+	captchaDBs.Store(name, d)
+	db, err := sql.Open("captcha", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db, d
+}
+
+func init() {
+	sql.Register("captcha", captchaOpener{})
+}
+
+type captchaOpener struct{}
+
+func (captchaOpener) Open(name string) (driver.Conn, error) {
+	d, ok := captchaDBs.Load(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown db %s", name)
+	}
+	return &captchaConn{d: d.(*captchaDriver)}, nil
+}
+
+type captchaConn struct {
+	d    *captchaDriver
+	inTx bool
+}
+
+func (c *captchaConn) Prepare(query string) (driver.Stmt, error) {
+	return &captchaStmt{c: c, query: query}, nil
+}
+func (c *captchaConn) Close() error { return nil }
+
+// Begin 以表锁模拟事务隔离, 事务结束时释放
+func (c *captchaConn) Begin() (driver.Tx, error) {
+	c.d.mu.Lock()
+	c.inTx = true
+	return captchaTx{c}, nil
+}
+
+type captchaTx struct{ c *captchaConn }
+
+func (tx captchaTx) Commit() error   { tx.c.inTx = false; tx.c.d.mu.Unlock(); return nil }
+func (tx captchaTx) Rollback() error { tx.c.inTx = false; tx.c.d.mu.Unlock(); return nil }
+
+type captchaStmt struct {
+	c     *captchaConn
+	query string
+}
+
+func (s *captchaStmt) Close() error  { return nil }
+func (s *captchaStmt) NumInput() int { return -1 }
+
+// lock 事务外的语句持有表锁执行, 返回释放函数
+func (s *captchaStmt) lock() func() {
+	if s.c.inTx {
+		return func() {}
+	}
+	s.c.d.mu.Lock()
+	return func() {
+		s.c.d.mu.Unlock()
+		time.Sleep(s.c.d.delay)
+	}
+}
+
+func (s *captchaStmt) Exec(args []driver.Value) (driver.Result, error) {
+	defer s.lock()()
+	rows := s.c.d.rows
+	switch {
+	case strings.HasPrefix(s.query, "DELETE FROM captcha WHERE id = ? AND value = ?"):
+		if row, ok := rows[args[0].(string)]; ok && row.value == args[1].(string) {
+			delete(rows, args[0].(string))
+			return driver.RowsAffected(1), nil
+		}
+		return driver.RowsAffected(0), nil
+	case strings.HasPrefix(s.query, "DELETE FROM captcha WHERE id = ?"):
+		if _, ok := rows[args[0].(string)]; ok {
+			delete(rows, args[0].(string))
+			return driver.RowsAffected(1), nil
+		}
+		return driver.RowsAffected(0), nil
+	case strings.HasPrefix(s.query, "DELETE FROM captcha WHERE expire_at <= ?"):
+		var n int64
+		for id, row := range rows {
+			if row.expireAt <= args[0].(int64) {
+				delete(rows, id)
+				n++
+			}
+		}
+		return driver.RowsAffected(n), nil
+	case strings.HasPrefix(s.query, "INSERT INTO captcha (id, value, expire_at)"):
+		if _, ok := rows[args[0].(string)]; ok {
+			return nil, errors.New("duplicate key")
+		}
+		rows[args[0].(string)] = captchaRow{args[1].(string), args[2].(int64)}
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(s.query, "UPDATE captcha SET value = ?, expire_at = ? WHERE id = ? AND value = ? AND expire_at = ?"):
+		row, ok := rows[args[2].(string)]
+		if !ok || row.value != args[3].(string) || row.expireAt != args[4].(int64) {
+			return driver.RowsAffected(0), nil
+		}
+		rows[args[2].(string)] = captchaRow{args[0].(string), args[1].(int64)}
+		return driver.RowsAffected(1), nil
+	}
+	return nil, fmt.Errorf("unexpected query %s", s.query)
+}
+
+func (s *captchaStmt) Query(args []driver.Value) (driver.Rows, error) {
+	defer s.lock()()
+	if !strings.HasPrefix(s.query, "SELECT value, expire_at FROM captcha WHERE id = ?") {
+		return nil, fmt.Errorf("unexpected query %s", s.query)
+	}
+	row, ok := s.c.d.rows[args[0].(string)]
+	if !ok {
+		return &captchaRows{done: true}, nil
+	}
+	return &captchaRows{row: []driver.Value{row.value, row.expireAt}}, nil
+}
+
+type captchaRows struct {
+	row  []driver.Value
+	done bool
+}
+
+func (r *captchaRows) Columns() []string { return []string{"value", "expire_at"} }
+func (r *captchaRows) Close() error      { return nil }
+func (r *captchaRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	copy(dest, r.row)
+	return nil
+}
+
+func TestSQLStore(t *testing.T) {
+	ctx := context.Background()
+	db, d := openCaptchaDB(t, 0)
+	s := captcha.NewSQLStore(db, "captcha")
+	testStore(t, s)
+
+	// Set 覆盖已有记录
+	_ = s.Set(ctx, "k", "v1", time.Minute)
+	_ = s.Set(ctx, "k", "v2", time.Minute)
+	if v, err := s.Get(ctx, "k"); err != nil || v != "v2" {
+		t.Error("覆盖写入错误", v, err)
+	}
+
+	_ = s.Set(ctx, "old", "v", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if n, err := s.Cleanup(ctx); err != nil || n < 1 {
+		t.Error("清理过期数据错误", n, err)
+	}
+	d.mu.Lock()
+	_, ok := d.rows["old"]
+	d.mu.Unlock()
+	if ok {
+		t.Error("过期数据理应已删除")
+	}
+
+	stop := s.StartCleanup(time.Millisecond)
+	_ = s.Set(ctx, "old", "v", time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	stop()
+	d.mu.Lock()
+	_, ok = d.rows["old"]
+	d.mu.Unlock()
+	if ok {
+		t.Error("后台清理理应已删除过期数据")
+	}
+}
+
+func TestSQLStoreConcurrent(t *testing.T) {
+	ctx := context.Background()
+	db, _ := openCaptchaDB(t, time.Millisecond)
+	s := captcha.NewSQLStore(db, "captcha")
+
+	// 并发读取并删除同一记录, 只有一个请求取得
+	_ = s.Set(ctx, "k", "v", time.Minute)
+	var (
+		wg    sync.WaitGroup
+		taken atomic.Int32
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := s.Take(ctx, "k"); err == nil && v == "v" {
+				taken.Add(1)
+			} else if !errors.Is(err, captcha.ErrNotFound) {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if taken.Load() != 1 {
+		t.Error("并发读取并删除只能有一个请求取得记录", taken.Load())
+	}
+
+	// 并发计数 (含首次插入竞争), 每个请求得到不同的值
+	var (
+		mu   sync.Mutex
+		seen = make(map[int64]bool)
+	)
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n, err := s.Incr(ctx, "n", time.Minute)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if seen[n] {
+				t.Error("计数重复", n)
+			}
+			seen[n] = true
+		}()
+	}
+	wg.Wait()
+	if n, _ := s.Incr(ctx, "n", time.Minute); n != 31 {
+		t.Error("并发计数理应精确", n)
+	}
+}
@@ -0,0 +1,244 @@
+package captcha_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ackcoder/go-mods/captcha"
+)
+
+// fakeRESP 进程内 RESP 服务, 仅支持 AUTH/SELECT/SET PX/GET/GETDEL/DEL 及读取并删除, 计数加一的 EVAL 脚本
+type fakeRESP struct {
+	ln   net.Listener
+	mu   sync.Mutex
+	data map[string]fakeItem
+
+	delay    time.Duration //每条命令的处理延迟, 模拟网络延迟
+	noGetDel atomic.Bool   //模拟不支持 GETDEL 的旧版本
+}
+
+type fakeItem struct {
+	value    string
+	expireAt time.Time
+}
+
+func newFakeRESP(t *testing.T, password string) *fakeRESP {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeRESP{ln: ln, data: make(map[string]fakeItem)}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn, password)
+		}
+	}()
+	return f
+}
+
+func (f *fakeRESP) serve(conn net.Conn, password string) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	authed := password == ""
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(args[0])
+		time.Sleep(f.delay)
+		if cmd == "GETDEL" && f.noGetDel.Load() {
+			io.WriteString(conn, "-ERR unknown command 'GETDEL'\r\n")
+			continue
+		}
+		if !authed && cmd != "AUTH" {
+			io.WriteString(conn, "-NOAUTH Authentication required.\r\n")
+			continue
+		}
+		if cmd == "EVAL" && strings.Contains(args[1], "INCR") {
+			cmd = "INCR"
+		}
+		f.mu.Lock()
+		switch cmd {
+		case "AUTH":
+			if args[1] != password {
+				io.WriteString(conn, "-WRONGPASS invalid password\r\n")
+			} else {
+				authed = true
+				io.WriteString(conn, "+OK\r\n")
+			}
+		case "SELECT":
+			io.WriteString(conn, "+OK\r\n")
+		case "SET":
+			ms, _ := strconv.Atoi(args[4])
+			f.data[args[1]] = fakeItem{args[2], time.Now().Add(time.Duration(ms) * time.Millisecond)}
+			io.WriteString(conn, "+OK\r\n")
+		case "GET", "GETDEL", "EVAL":
+			key := args[1]
+			if cmd == "EVAL" {
+				key = args[3]
+			}
+			item, ok := f.data[key]
+			if cmd != "GET" {
+				delete(f.data, key)
+			}
+			if !ok || time.Now().After(item.expireAt) {
+				io.WriteString(conn, "$-1\r\n")
+			} else {
+				fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(item.value), item.value)
+			}
+		case "INCR":
+			item, ok := f.data[args[3]]
+			if !ok || time.Now().After(item.expireAt) {
+				ms, _ := strconv.Atoi(args[4])
+				item = fakeItem{"0", time.Now().Add(time.Duration(ms) * time.Millisecond)}
+			}
+			n, _ := strconv.Atoi(item.value)
+			item.value = strconv.Itoa(n + 1)
+			f.data[args[3]] = item
+			fmt.Fprintf(conn, ":%d\r\n", n+1)
+		case "DEL":
+			_, ok := f.data[args[1]]
+			delete(f.data, args[1])
+			fmt.Fprintf(conn, ":%d\r\n", map[bool]int{true: 1}[ok])
+		default:
+			io.WriteString(conn, "-ERR unknown command\r\n")
+		}
+		f.mu.Unlock()
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, n)
+	for i := range args {
+		if line, err = r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		b := make([]byte, size+2)
+		if _, err = io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		args[i] = string(b[:size])
+	}
+	return args, nil
+}
+
+func testStore(t *testing.T, s captcha.Store) {
+	ctx := context.Background()
+	if err := s.Set(ctx, "k1", "v1", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := s.Get(ctx, "k1"); err != nil || v != "v1" {
+		t.Error("读取错误", v, err)
+	}
+	if err := s.Delete(ctx, "k1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(ctx, "k1"); !errors.Is(err, captcha.ErrNotFound) {
+		t.Error("删除后理应不存在", err)
+	}
+
+	_ = s.Set(ctx, "k3", "v3", time.Minute)
+	if v, err := s.Take(ctx, "k3"); err != nil || v != "v3" {
+		t.Error("读取并删除错误", v, err)
+	}
+	if _, err := s.Take(ctx, "k3"); !errors.Is(err, captcha.ErrNotFound) {
+		t.Error("读取并删除后理应不存在", err)
+	}
+
+	if n, err := s.Incr(ctx, "k4", 20*time.Millisecond); err != nil || n != 1 {
+		t.Error("计数错误", n, err)
+	}
+	if n, err := s.Incr(ctx, "k4", time.Minute); err != nil || n != 2 {
+		t.Error("计数错误", n, err)
+	}
+
+	_ = s.Set(ctx, "k2", "v2", 20*time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+	if _, err := s.Get(ctx, "k2"); !errors.Is(err, captcha.ErrNotFound) {
+		t.Error("过期后理应不存在", err)
+	}
+	// 计数加一不延长过期时间
+	if n, err := s.Incr(ctx, "k4", time.Minute); err != nil || n != 1 {
+		t.Error("过期后理应重新计数", n, err)
+	}
+	_ = s.Delete(ctx, "k4")
+}
+
+func TestMemoryStore(t *testing.T) {
+	s := captcha.NewMemoryStore(2)
+	testStore(t, s)
+	_ = s.Set(context.Background(), "a", "1", time.Nanosecond)
+	_ = s.Set(context.Background(), "b", "1", time.Minute) //第二次写入触发清理
+	if s.Len() != 1 {
+		t.Error("过期项未清理", s.Len())
+	}
+}
+
+func TestRESPStore(t *testing.T) {
+	f := newFakeRESP(t, "secret")
+	s := captcha.NewRESPStore(f.ln.Addr().String(), &captcha.RESPOption{Password: "secret", DB: 1})
+	defer s.Close()
+	testStore(t, s)
+
+	// 不支持 GETDEL 时使用 EVAL 脚本
+	f.noGetDel.Store(true)
+	testStore(t, s)
+	f.noGetDel.Store(false)
+
+	bad := captcha.NewRESPStore(f.ln.Addr().String(), &captcha.RESPOption{Password: "wrong"})
+	if err := bad.Set(context.Background(), "k", "v", time.Minute); err == nil {
+		t.Error("密码错误理应报错")
+	}
+}
+
+func TestSharedStore(t *testing.T) {
+	f := newFakeRESP(t, "")
+	store := captcha.NewRESPStore(f.ln.Addr().String())
+	// 模拟负载均衡后的两个实例
+	a := captcha.New(4, 60, captcha.WithTypeDigit(100, 36), captcha.WithStore(store))
+	b := captcha.New(4, 60, captcha.WithStore(store), captcha.WithTypeDigit(100, 36))
+
+	id, _, err := a.Make(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	answer := answerOf(t, store, id)
+	if !b.Check(id, answer) {
+		t.Error("其他实例理应校验通过")
+	}
+	if a.Check(id, answer) {
+		t.Error("校验后理应失效")
+	}
+}
+
+// answerOf 从存储中读取验证码答案, 记录键为 "c:" + 验证码ID, 格式为 "过期时间|已尝试次数|类型名|答案"
+func answerOf(t *testing.T, store captcha.Store, id string) string {
+	t.Helper()
+	v, err := store.Get(context.Background(), "c:"+id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.SplitN(v, "|", 4)[3]
+}
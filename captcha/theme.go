@@ -0,0 +1,257 @@
+package captcha
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"io/fs"
+	"math"
+	"math/rand/v2"
+	"strings"
+
+	"github.com/golang/freetype"
+	"github.com/golang/freetype/truetype"
+	"github.com/mojocn/base64Captcha"
+)
+
+// LoadBackgrounds 从文件系统加载背景图, 用于 ImageOption.Backgrounds, SliderOption.Backgrounds 等
+//   - {fsys} 文件系统, 如 os.DirFS("assets"), embed.FS
+//   - {pattern} 文件匹配规则 (同 fs.Glob), 如 "bg/*.jpg", 支持 png, jpeg, gif
+func LoadBackgrounds(fsys fs.FS, pattern string) ([]image.Image, error) {
+	names, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("captcha: 没有匹配 %s 的背景图", pattern)
+	}
+	imgs := make([]image.Image, 0, len(names))
+	for _, name := range names {
+		f, err := fsys.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		img, _, err := image.Decode(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("captcha: 解码背景图 %s 失败: %w", name, err)
+		}
+		imgs = append(imgs, img)
+	}
+	return imgs, nil
+}
+
+// takeFonts 在创建时加载字体, 返回只含已加载字体的存储与字体列表
+//   - {def} 未设置字体时的默认字体, 为空时返回 nil 存储与 nil 列表 (base64Captcha 使用内置的全部字体)
+//
+// 字体不存在或解析失败时返回错误, 由 Make 等方法返回; 绘制时只使用已加载的字体, 不会 panic
+func takeFonts(def string, opt ...*ImageOption) (base64Captcha.FontsStorage, []string, error) {
+	var o ImageOption
+	if len(opt) != 0 && opt[0] != nil {
+		o = *opt[0]
+	}
+	var names []string
+	if o.Font != "" {
+		names = append(names, o.Font)
+	}
+	names = append(names, o.Fonts...)
+	if len(names) == 0 {
+		if def == "" {
+			return nil, nil, nil
+		}
+		names = []string{def}
+	}
+
+	fonts := make(loadedFonts, len(names))
+	for _, name := range names {
+		f, err := loadFont(o.FontFS, name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("captcha: 加载字体 %s 失败: %w", name, err)
+		}
+		fonts[name] = f
+	}
+	return fonts, names, nil
+}
+
+// loadFont 加载字体, {fsys} 为 nil 时从 base64Captcha.DefaultEmbeddedFonts 加载
+func loadFont(fsys fs.FS, name string) (f *truetype.Font, err error) {
+	if fsys == nil {
+		// 内置字体存储在字体不存在时 panic, 此处转为错误
+		defer func() {
+			if r := recover(); r != nil {
+				f, err = nil, fmt.Errorf("%v", r)
+			}
+		}()
+		return base64Captcha.DefaultEmbeddedFonts.LoadFontByName("fonts/" + name), nil
+	}
+	b, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	return freetype.ParseFont(b)
+}
+
+// loadedFonts 已加载的字体, 实现 base64Captcha.FontsStorage
+type loadedFonts map[string]*truetype.Font
+
+// LoadFontByName base64Captcha 驱动以 "fonts/" + 名称加载, 此处去除该前缀
+func (s loadedFonts) LoadFontByName(name string) *truetype.Font {
+	return s[strings.TrimPrefix(name, "fonts/")]
+}
+
+func (s loadedFonts) LoadFontsByNames(names []string) []*truetype.Font {
+	fonts := make([]*truetype.Font, 0, len(names))
+	for _, name := range names {
+		fonts = append(fonts, s.LoadFontByName(name))
+	}
+	return fonts
+}
+
+// ============================================================
+
+// theme 图片主题: 扭曲, 前景色板, 背景图
+//
+// 设置后内部驱动以透明背景绘制, 再依次扭曲, 替换前景色, 叠加到背景上
+type theme struct {
+	width, height int
+	bgColor       color.RGBA
+	backgrounds   []image.Image
+	palette       []color.NRGBA
+	distortion    float64
+}
+
+// newTheme 按选项创建主题, 未设置主题相关选项时返回 nil
+func newTheme(w, h int, bg *color.RGBA, opt ...*ImageOption) *theme {
+	if len(opt) == 0 || opt[0] == nil {
+		return nil
+	}
+	o := opt[0]
+	if len(o.Backgrounds) == 0 && len(o.Palette) == 0 && o.Distortion <= 0 {
+		return nil
+	}
+	t := &theme{width: w, height: h, backgrounds: o.Backgrounds, distortion: o.Distortion}
+	if bg != nil {
+		t.bgColor = *bg
+	}
+	for _, c := range o.Palette {
+		t.palette = append(t.palette, color.NRGBAModel.Convert(c).(color.NRGBA))
+	}
+	return t
+}
+
+// background 内部驱动使用的背景色
+func (t *theme) background(bg *color.RGBA) *color.RGBA {
+	if t == nil {
+		return bg
+	}
+	return &color.RGBA{}
+}
+
+// wrap 包装驱动, {t} 为 nil 时原样返回
+func (t *theme) wrap(d base64Captcha.Driver) base64Captcha.Driver {
+	if t == nil {
+		return d
+	}
+	return &themedDriver{Driver: d, theme: t}
+}
+
+type themedDriver struct {
+	base64Captcha.Driver
+	theme *theme
+}
+
+func (d *themedDriver) DrawCaptcha(question string) (base64Captcha.Item, error) {
+	item, err := d.Driver.DrawCaptcha(question)
+	if err != nil {
+		return nil, err
+	}
+	src, err := itemImage(item)
+	if err != nil {
+		return nil, err
+	}
+	t := d.theme
+	fg := image.NewNRGBA(src.Bounds())
+	draw.Draw(fg, fg.Bounds(), src, src.Bounds().Min, draw.Src)
+	if t.distortion > 0 {
+		fg = distort(fg, t.distortion)
+	}
+	if len(t.palette) != 0 {
+		recolor(fg, t.palette)
+	}
+
+	var dst *image.RGBA
+	if len(t.backgrounds) != 0 {
+		dst = drawBackground(t.width, t.height, t.backgrounds)
+	} else {
+		dst = image.NewRGBA(image.Rect(0, 0, t.width, t.height))
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(t.bgColor), image.Point{}, draw.Src)
+	}
+	draw.Draw(dst, dst.Bounds(), fg, fg.Bounds().Min, draw.Over)
+	return &imageItem{img: dst}, nil
+}
+
+// distort 正弦波形扭曲, {amp} 为振幅(像素)
+func distort(src *image.NRGBA, amp float64) *image.NRGBA {
+	b := src.Bounds()
+	dst := image.NewNRGBA(b)
+	period := float64(b.Dy()) * (1.5 + rand.Float64())
+	phaseX, phaseY := rand.Float64()*2*math.Pi, rand.Float64()*2*math.Pi
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			sx := x + int(amp*math.Sin(2*math.Pi*float64(y)/period+phaseX))
+			sy := y + int(amp*math.Sin(2*math.Pi*float64(x)/period+phaseY))
+			if image.Pt(sx, sy).In(b) {
+				dst.SetNRGBA(x, y, src.NRGBAAt(sx, sy))
+			}
+		}
+	}
+	return dst
+}
+
+// recolor 将不透明像素替换为色板中最接近的颜色 (保留透明度)
+func recolor(img *image.NRGBA, palette []color.NRGBA) {
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := img.NRGBAAt(x, y)
+			if c.A == 0 {
+				continue
+			}
+			best, bestDist := palette[0], math.MaxInt
+			for _, p := range palette {
+				dr, dg, db := int(c.R)-int(p.R), int(c.G)-int(p.G), int(c.B)-int(p.B)
+				if d := dr*dr + dg*dg + db*db; d < bestDist {
+					best, bestDist = p, d
+				}
+			}
+			best.A = c.A
+			img.SetNRGBA(x, y, best)
+		}
+	}
+}
+
+// imageItem 主题处理后的验证码图片, 实现 base64Captcha.Item
+type imageItem struct {
+	img image.Image
+}
+
+func (i *imageItem) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, i.img); err != nil {
+		return 0, err
+	}
+	return buf.WriteTo(w)
+}
+
+func (i *imageItem) EncodeB64string() string {
+	var buf bytes.Buffer
+	_, _ = i.WriteTo(&buf)
+	return "data:" + MimePNG + ";base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+}
@@ -0,0 +1,94 @@
+package captcha_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ackcoder/go-mods/captcha"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestThemeFontFS(t *testing.T) {
+	fsys := fstest.MapFS{"fonts/go.ttf": {Data: goregular.TTF}}
+	opt := &captcha.ImageOption{FontFS: fsys, Font: "fonts/go.ttf"}
+
+	for name, typ := range map[string]captcha.CaptchaType{
+		"string":  captcha.WithTypeString(120, 40, opt),
+		"math":    captcha.WithTypeMath(120, 40, opt),
+		"chinese": captcha.WithTypeChinese(120, 40, &captcha.ImageOption{FontFS: fsys, Font: "fonts/go.ttf", Source: "abc"}),
+	} {
+		_, data, _, err := captcha.New(4, 60, typ).MakeBytes()
+		if err != nil {
+			t.Error(name, err)
+			continue
+		}
+		if _, err = png.Decode(bytes.NewReader(data)); err != nil {
+			t.Error(name, "PNG 解码失败", err)
+		}
+	}
+
+	// 字体不存在时生成返回错误, 不 panic
+	for name, opt := range map[string]*captcha.ImageOption{
+		"fs":       {FontFS: fsys, Font: "none.ttf"},
+		"embedded": {Font: "none.ttf"},
+		"invalid":  {FontFS: fstest.MapFS{"bad.ttf": {Data: []byte("bad")}}, Font: "bad.ttf"},
+	} {
+		ins := captcha.New(4, 60, captcha.WithTypeString(120, 40, opt))
+		if _, _, err := ins.Make(false); err == nil {
+			t.Error(name, "字体加载失败时理应返回错误")
+		}
+	}
+	// 重新设置类型后错误清除
+	ins := captcha.New(4, 60, captcha.WithTypeMath(120, 40, &captcha.ImageOption{Font: "none.ttf"}), captcha.WithTypeMath(120, 40))
+	if _, _, err := ins.Make(false); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestThemeBackgrounds(t *testing.T) {
+	var buf bytes.Buffer
+	bg := image.NewRGBA(image.Rect(0, 0, 60, 20))
+	for i := range bg.Pix {
+		bg.Pix[i] = 0xff
+	}
+	_ = png.Encode(&buf, bg)
+	bgs, err := captcha.LoadBackgrounds(fstest.MapFS{"bg/1.png": {Data: buf.Bytes()}}, "bg/*.png")
+	if err != nil || len(bgs) != 1 {
+		t.Fatal(err)
+	}
+	if _, err = captcha.LoadBackgrounds(fstest.MapFS{}, "bg/*.png"); err == nil {
+		t.Error("没有背景图时应返回错误")
+	}
+
+	red := color.RGBA{R: 200, A: 255}
+	ins := captcha.New(4, 60, captcha.WithTypeString(120, 40, &captcha.ImageOption{
+		Backgrounds: bgs,
+		Palette:     []color.Color{red},
+		Distortion:  3,
+		LineLevel:   -1,
+	}))
+	_, data, mime, err := ins.MakeBytes()
+	if err != nil || mime != captcha.MimePNG {
+		t.Fatal(mime, err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 120 || b.Dy() != 40 {
+		t.Error("尺寸错误", b)
+	}
+	// 背景为白色, 前景只能是白色与红色的混合
+	for y := 0; y < 40; y++ {
+		for x := 0; x < 120; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			if g != b || r < g {
+				t.Fatal("存在色板外的颜色", x, y, r>>8, g>>8, b>>8)
+			}
+		}
+	}
+}
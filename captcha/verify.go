@@ -0,0 +1,184 @@
+package captcha
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrExpired 验证码已过期
+	ErrExpired = errors.New("captcha: expired")
+	// ErrMismatch 验证码错误
+	ErrMismatch = errors.New("captcha: mismatch")
+	// ErrTooManyAttempts 错误次数过多, 验证码已失效
+	ErrTooManyAttempts = errors.New("captcha: too many attempts")
+	// ErrUnsupported 当前验证码类型不支持该操作, 如滑块验证码需使用 MakeSlider
+	ErrUnsupported = errors.New("captcha: unsupported for this captcha type")
+)
+
+// expiredKeep 过期后记录的保留时长, 期间校验返回 ErrExpired 而非 ErrNotFound
+const expiredKeep = time.Minute
+
+// recordPrefix 验证码记录的存储键前缀
+//
+// 与共用存储的其他数据 (如 Guard 失败计数, 工作量证明难度计数) 隔离,
+// 防止客户端以其他数据的键作为验证码ID 提交, 借 Store.Take 删除这些数据
+const recordPrefix = "c:"
+
+// Result 校验结果
+type Result struct {
+	OK        bool //是否通过
+	Attempts  int  //已尝试次数 (含本次)
+	Remaining int  //剩余可尝试次数, 不限次数时为 -1, 验证码失效后为 0
+}
+
+// WithMaxAttempts 每个验证码最多可尝试次数, 达到后失效
+//   - {n} 次数, 小于等于0表示不限 (直至过期)
+//
+// 注: 设置后校验失败不再立即清除验证码 (默认失败即清除); 无状态模式 (WithStateless) 下不生效
+func WithMaxAttempts(n int) CaptchaType {
+	return func(c *Captcha) {
+		c.keepOnFailure = true
+		c.maxAttempts = max(n, 0)
+	}
+}
+
+// WithKeepOnFailure 校验失败时不清除验证码, 可重试直至过期或达到 WithMaxAttempts 次数
+//
+// 注: 无状态模式 (WithStateless) 下不生效, 令牌使用一次即失效
+func WithKeepOnFailure() CaptchaType {
+	return func(c *Captcha) {
+		c.keepOnFailure = true
+	}
+}
+
+// record 存储的验证码记录, 格式为 "过期毫秒时间戳|已尝试次数|类型名|答案"
+type record struct {
+	expireAt time.Time
+	attempts int
+	typ      string
+	answer   string
+}
+
+func (r record) encode() string {
+	return strconv.FormatInt(r.expireAt.UnixMilli(), 10) + "|" + strconv.Itoa(r.attempts) + "|" + r.typ + "|" + r.answer
+}
+
+func decodeRecord(s string) (r record, err error) {
+	parts := strings.SplitN(s, "|", 4)
+	if len(parts) != 4 {
+		return r, fmt.Errorf("captcha: 记录格式错误")
+	}
+	ms, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return r, fmt.Errorf("captcha: 记录格式错误: %w", err)
+	}
+	if r.attempts, err = strconv.Atoi(parts[1]); err != nil {
+		return r, fmt.Errorf("captcha: 记录格式错误: %w", err)
+	}
+	r.expireAt = time.UnixMilli(ms)
+	r.typ = parts[2]
+	r.answer = parts[3]
+	return r, nil
+}
+
+// save 保存答案, 返回客户端使用的验证码ID (无状态模式下为令牌)
+func (c *Captcha) save(ctx context.Context, idKey, answer string) (string, error) {
+	r := record{expireAt: time.Now().Add(c.expiration), typ: c.typ, answer: answer}
+	if c.stateless != nil {
+		return c.stateless.issue(r, c.dirver != nil)
+	}
+	return idKey, c.store.Set(ctx, recordPrefix+idKey, r.encode(), c.expiration+expiredKeep)
+}
+
+// Verify 校验验证码 (不区分大小写)
+//   - {idKey} 验证码ID
+//   - {code} 用户提交的验证码
+//
+// 通过时返回 OK 为 true 的结果并清除验证码; 未通过时返回 ErrNotFound, ErrExpired,
+// ErrMismatch 或 ErrTooManyAttempts (达到 WithMaxAttempts 次数, 本次亦未通过);
+// 验证码ID 由其他类型的验证码生成 (如共用存储时用数字验证码ID 校验滑块验证码) 时返回 ErrNotFound
+//
+// 注: 记录以原子操作 (Store.Take) 取出后再比对, 并发提交同一验证码时只有一个请求参与比对,
+// 其余返回 ErrNotFound; 设置 WithKeepOnFailure/WithMaxAttempts 时比对失败后放回记录以便重试
+func (c *Captcha) Verify(idKey, code string) (Result, error) {
+	return c.VerifyContext(context.Background(), idKey, code)
+}
+
+// VerifyContext 同 Verify, 可传入上下文用于存储操作
+func (c *Captcha) VerifyContext(ctx context.Context, idKey, code string) (Result, error) {
+	return c.verify(ctx, idKey, func(answer string) error {
+		if code == "" {
+			return ErrMismatch
+		}
+		if c.stateless != nil && c.dirver != nil {
+			code = c.stateless.hashAnswer(code) //无状态模式下文字答案仅保存哈希
+		}
+		return c.match(answer, code)
+	})
+}
+
+// verify 校验流程, {match} 比对答案, 不通过时返回 ErrMismatch (可包装原因)
+func (c *Captcha) verify(ctx context.Context, idKey string, match func(answer string) error) (res Result, err error) {
+	if idKey == "" {
+		return res, ErrNotFound
+	}
+	if c.stateless != nil {
+		return c.stateless.verify(idKey, c.typ, match)
+	}
+	v, err := c.store.Take(ctx, recordPrefix+idKey)
+	if err != nil {
+		return res, err
+	}
+	r, err := decodeRecord(v)
+	if err != nil {
+		return res, err
+	}
+	if r.typ != c.typ {
+		return res, fmt.Errorf("%w: 验证码类型不符", ErrNotFound)
+	}
+
+	now := time.Now()
+	if !now.Before(r.expireAt) {
+		return res, ErrExpired
+	}
+	if c.maxAttempts > 0 && r.attempts >= c.maxAttempts {
+		return Result{Attempts: r.attempts}, ErrTooManyAttempts
+	}
+
+	r.attempts++
+	res = Result{Attempts: r.attempts, Remaining: -1}
+	mErr := match(r.answer)
+	if mErr == nil {
+		res.OK = true
+		return res, nil
+	}
+
+	if !c.keepOnFailure {
+		res.Remaining = 0
+		return res, mErr
+	}
+	if c.maxAttempts > 0 {
+		res.Remaining = c.maxAttempts - r.attempts
+		if res.Remaining == 0 {
+			return res, ErrTooManyAttempts
+		}
+	}
+	// 放回记录以便重试
+	if err = c.store.Set(ctx, recordPrefix+idKey, r.encode(), r.expireAt.Sub(now)+expiredKeep); err != nil {
+		return res, err
+	}
+	return res, mErr
+}
+
+// matchText 文本答案比对, 不区分大小写
+func matchText(answer, code string) error {
+	if !strings.EqualFold(answer, code) {
+		return ErrMismatch
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package captcha_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ackcoder/go-mods/captcha"
+)
+
+func TestVerify(t *testing.T) {
+	store := captcha.NewMemoryStore()
+	ins := captcha.New(4, 60, captcha.WithTypeDigit(100, 36), captcha.WithStore(store))
+
+	if _, err := ins.Verify("unknown", "1234"); !errors.Is(err, captcha.ErrNotFound) {
+		t.Error("不存在的ID 理应返回 ErrNotFound", err)
+	}
+
+	// 默认失败即失效
+	id, _, _ := ins.Make(false)
+	if _, err := ins.Verify(id, "wrong"); !errors.Is(err, captcha.ErrMismatch) {
+		t.Error("理应返回 ErrMismatch", err)
+	}
+	if _, err := ins.Verify(id, "wrong"); !errors.Is(err, captcha.ErrNotFound) {
+		t.Error("失败后理应失效", err)
+	}
+
+	// 通过
+	id, _, _ = ins.Make(false)
+	res, err := ins.Verify(id, answerOf(t, store, id))
+	if err != nil || !res.OK {
+		t.Error("理应校验通过", res, err)
+	}
+}
+
+func TestVerifyMaxAttempts(t *testing.T) {
+	store := captcha.NewMemoryStore()
+	ins := captcha.New(4, 60, captcha.WithTypeDigit(100, 36), captcha.WithStore(store), captcha.WithMaxAttempts(3))
+
+	id, _, _ := ins.Make(false)
+	answer := answerOf(t, store, id)
+	for i := 1; i <= 2; i++ {
+		res, err := ins.Verify(id, "wrong")
+		t.Logf("%+v %v", res, err)
+		if !errors.Is(err, captcha.ErrMismatch) || res.Remaining != 3-i {
+			t.Fatal("理应返回 ErrMismatch", res, err)
+		}
+	}
+	if _, err := ins.Verify(id, "wrong"); !errors.Is(err, captcha.ErrTooManyAttempts) {
+		t.Error("理应返回 ErrTooManyAttempts", err)
+	}
+	if ins.Check(id, answer) {
+		t.Error("达到次数后理应失效")
+	}
+
+	// 重试后通过
+	id, _, _ = ins.Make(false)
+	ins.Check(id, "wrong")
+	if !ins.Check(id, answerOf(t, store, id)) {
+		t.Error("未达次数时理应可重试")
+	}
+}
+
+func TestVerifyExpired(t *testing.T) {
+	store := captcha.NewMemoryStore()
+	ins := captcha.New(4, 0, captcha.WithTypeDigit(100, 36), captcha.WithStore(store), captcha.WithKeepOnFailure())
+	id, _, _ := ins.Make(false)
+	time.Sleep(time.Millisecond)
+	if _, err := ins.Verify(id, answerOf(t, store, id)); !errors.Is(err, captcha.ErrExpired) {
+		t.Error("理应返回 ErrExpired", err)
+	}
+}
+
+func TestVerifyConcurrent(t *testing.T) {
+	f := newFakeRESP(t, "")
+	f.delay = 2 * time.Millisecond
+	store := captcha.NewRESPStore(f.ln.Addr().String(), &captcha.RESPOption{PoolSize: 64})
+	defer store.Close()
+
+	// 同一验证码并发提交正确答案, 只能通过一次
+	ins := captcha.New(4, 60, captcha.WithTypeDigit(100, 36), captcha.WithStore(store))
+	id, _, _ := ins.Make(false)
+	answer := answerOf(t, store, id)
+	var passed atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ins.Check(id, answer) {
+				passed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if passed.Load() != 1 {
+		t.Error("并发提交只能通过一次", passed.Load())
+	}
+
+	// 并发猜测不能超过最多尝试次数
+	ins = captcha.New(4, 60, captcha.WithTypeDigit(100, 36), captcha.WithStore(store), captcha.WithMaxAttempts(3))
+	id, _, _ = ins.Make(false)
+	var evaluated atomic.Int32
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if res, _ := ins.Verify(id, "wrong"); res.Attempts > 0 {
+				evaluated.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if evaluated.Load() > 3 {
+		t.Error("比对次数超出最多尝试次数", evaluated.Load())
+	}
+}
@@ -0,0 +1,372 @@
+// idgen ID 生成/解析命令行工具
+//
+// 用法:
+//
+//	idgen gen   [-scheme snowflake] [-n 1] [-format b10] [节点参数]
+//	idgen parse [-scheme auto] [-format b10] [节点参数] ID...
+//	idgen range [-format b10] [节点参数] -start 时间 [-end 时间]
+//
+// 节点参数: -layout twitter -epoch 2024-01-01T00:00:00Z -dc 0 -worker 0,
+// 解析与范围计算时需与生成时一致
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ackcoder/go-mods/idgen"
+)
+
+const usage = `用法: idgen <命令> [参数]
+
+命令:
+  gen    生成ID (snowflake, uuid4, uuid7, ulid, ksuid, xid, nanoid)
+  parse  解析ID (雪花算法ID 输出时间/集群/程序/序列号, ULID/UUIDv7/KSUID/XID 输出时间)
+  range  计算时间范围内的雪花算法ID 最小/最大值
+
+使用 "idgen <命令> -h" 查看命令参数
+`
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "idgen:", err)
+		}
+		os.Exit(2)
+	}
+}
+
+// run 执行命令, 便于测试
+func run(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return flag.ErrHelp
+	}
+	switch args[0] {
+	case "gen":
+		return cmdGen(args[1:], out)
+	case "parse":
+		return cmdParse(args[1:], out)
+	case "range":
+		return cmdRange(args[1:], out)
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(out, usage)
+		return nil
+	}
+	return fmt.Errorf("未知命令 %q\n\n%s", args[0], usage)
+}
+
+// nodeFlags 雪花算法节点参数
+type nodeFlags struct {
+	layout string
+	epoch  string
+	dc     uint64
+	worker uint64
+	format string
+}
+
+func (n *nodeFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&n.layout, "layout", "twitter", "位布局预设: twitter, sonyflake, baidu")
+	fs.StringVar(&n.epoch, "epoch", "", "起始点, RFC3339 或 2006-01-02 (UTC), 默认 2024-01-01 (UTC)")
+	fs.Uint64Var(&n.dc, "dc", 0, "集群ID")
+	fs.Uint64Var(&n.worker, "worker", 0, "程序ID")
+	fs.StringVar(&n.format, "format", "b10", "雪花算法ID 字串格式: b10, b16, b36")
+}
+
+func (n *nodeFlags) generator() (*idgen.IdGenerator, error) {
+	conf := idgen.GeneratorConfig{Layout: n.layout, DataCenterId: n.dc, WorkerId: n.worker}
+	if n.epoch != "" {
+		// 与包默认起始点一致, 日期按 UTC 解析
+		t, err := parseTime(n.epoch, time.UTC)
+		if err != nil {
+			return nil, err
+		}
+		conf.Epoch = t
+	}
+	return conf.Build()
+}
+
+func (n *nodeFlags) encoding() (*idgen.Encoding, error) {
+	switch n.format {
+	case "b10", "":
+		return nil, nil
+	case "b16":
+		return idgen.Base16, nil
+	case "b36":
+		return idgen.Base36, nil
+	}
+	return nil, fmt.Errorf("未知格式 %q", n.format)
+}
+
+// formatId 按格式输出雪花算法ID
+func formatId(id uint64, enc *idgen.Encoding) string {
+	if enc == nil {
+		return fmt.Sprint(id)
+	}
+	return enc.Encode(id)
+}
+
+// ============================================================
+
+func cmdGen(args []string, out io.Writer) error {
+	var node nodeFlags
+	fs := flag.NewFlagSet("gen", flag.ContinueOnError)
+	scheme := fs.String("scheme", "snowflake", "ID 类型: snowflake, uuid4, uuid7, ulid, ksuid, xid, nanoid")
+	count := fs.Int("n", 1, "生成数量")
+	node.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *count <= 0 {
+		return fmt.Errorf("生成数量应大于 0")
+	}
+
+	if *scheme == "snowflake" {
+		ig, err := node.generator()
+		if err != nil {
+			return err
+		}
+		enc, err := node.encoding()
+		if err != nil {
+			return err
+		}
+		ids, err := ig.GenBatch(*count)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			fmt.Fprintln(out, formatId(id, enc))
+		}
+		return nil
+	}
+	if *scheme == "nanoid" {
+		g, err := idgen.NewNanoid("", 0)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < *count; i++ {
+			s, err := g.Next()
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(out, s)
+		}
+		return nil
+	}
+
+	g, err := schemeGenerator(*scheme)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < *count; i++ {
+		id, err := g.Next()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, id.String())
+	}
+	return nil
+}
+
+func schemeGenerator(scheme string) (idgen.Generator, error) {
+	switch scheme {
+	case "uuid4":
+		return idgen.NewUUIDv4(), nil
+	case "uuid7":
+		return idgen.NewUUIDv7(), nil
+	case "ulid":
+		return idgen.NewULID(), nil
+	case "ksuid":
+		return idgen.NewKSUID(), nil
+	case "xid":
+		return idgen.NewXID(), nil
+	}
+	return nil, fmt.Errorf("未知ID 类型 %q", scheme)
+}
+
+// ============================================================
+
+// parseResult 解析结果
+type parseResult struct {
+	Input  string        `json:"input"`
+	Scheme string        `json:"scheme"`
+	Time   time.Time     `json:"time"`
+	Info   *idgen.IdInfo `json:"info,omitempty"` //雪花算法ID 各字段
+}
+
+func cmdParse(args []string, out io.Writer) error {
+	var node nodeFlags
+	fs := flag.NewFlagSet("parse", flag.ContinueOnError)
+	scheme := fs.String("scheme", "auto", "ID 类型: auto, snowflake, uuid, ulid, ksuid, xid")
+	asJson := fs.Bool("json", false, "以 JSON 格式输出")
+	node.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("缺少待解析的ID")
+	}
+	ig, err := node.generator()
+	if err != nil {
+		return err
+	}
+	enc, err := node.encoding()
+	if err != nil {
+		return err
+	}
+
+	for _, s := range fs.Args() {
+		res, err := parseOne(ig, enc, *scheme, s)
+		if err != nil {
+			return err
+		}
+		if *asJson {
+			b, _ := json.Marshal(res)
+			fmt.Fprintln(out, string(b))
+			continue
+		}
+		fmt.Fprintf(out, "%s\n  scheme: %s\n  time:   %s\n", res.Input, res.Scheme, res.Time.Local().Format(time.RFC3339Nano))
+		if res.Info != nil {
+			fmt.Fprintf(out, "  id:     %d\n  dc:     %d\n  worker: %d\n  seq:    %d\n",
+				res.Info.Id, res.Info.DataCenterId, res.Info.WorkerId, res.Info.Sequence)
+			if ig.Layout().RollbackBits != 0 {
+				fmt.Fprintf(out, "  rollback: %d\n", res.Info.Rollback)
+			}
+		}
+	}
+	return nil
+}
+
+// parseOne 解析单个ID, {scheme} 为 auto 时按长度与字符识别类型
+func parseOne(ig *idgen.IdGenerator, enc *idgen.Encoding, scheme, s string) (*parseResult, error) {
+	if scheme == "auto" {
+		scheme = detectScheme(s, enc)
+	}
+	res := &parseResult{Input: s, Scheme: scheme}
+	var (
+		id  idgen.Identifier
+		err error
+	)
+	switch scheme {
+	case "snowflake":
+		var v uint64
+		if enc == nil {
+			var info idgen.IdInfo
+			info, err = ig.ParseString(s)
+			v = info.Id
+		} else {
+			v, err = enc.Decode(s)
+		}
+		if err != nil {
+			return nil, err
+		}
+		info := ig.Parse(v)
+		res.Time, res.Info = info.Time, &info
+		return res, nil
+	case "uuid":
+		var u idgen.UUID
+		if u, err = idgen.ParseUUID(s); err == nil {
+			res.Scheme = fmt.Sprintf("uuid%d", u.Version())
+			id = u
+		}
+	case "ulid":
+		id, err = idgen.ParseULID(s)
+	case "ksuid":
+		id, err = idgen.ParseKSUID(s)
+	case "xid":
+		id, err = idgen.ParseXID(s)
+	default:
+		return nil, fmt.Errorf("无法识别ID %q 的类型, 请使用 -scheme 指定", s)
+	}
+	if err != nil {
+		return nil, err
+	}
+	res.Time = id.Time()
+	return res, nil
+}
+
+// detectScheme 按字串特征识别ID 类型
+func detectScheme(s string, enc *idgen.Encoding) string {
+	switch {
+	case enc != nil:
+		return "snowflake"
+	case len(s) == 36 && strings.Count(s, "-") == 4:
+		return "uuid"
+	case len(s) == 26:
+		return "ulid"
+	case len(s) == 27:
+		return "ksuid"
+	case len(s) == 20 && strings.Trim(s, "0123456789") != "":
+		return "xid"
+	case strings.Trim(s, "0123456789") == "" && s != "":
+		return "snowflake"
+	}
+	return ""
+}
+
+// ============================================================
+
+func cmdRange(args []string, out io.Writer) error {
+	var node nodeFlags
+	fs := flag.NewFlagSet("range", flag.ContinueOnError)
+	start := fs.String("start", "", "开始时间, RFC3339 或 2006-01-02 (必填)")
+	end := fs.String("end", "", "结束时间, 默认与开始时间相同; 仅日期时表示当天结束")
+	node.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *start == "" {
+		return fmt.Errorf("缺少 -start 参数")
+	}
+	ig, err := node.generator()
+	if err != nil {
+		return err
+	}
+	enc, err := node.encoding()
+	if err != nil {
+		return err
+	}
+
+	from, err := parseTime(*start, time.Local)
+	if err != nil {
+		return err
+	}
+	endStr := *end
+	if endStr == "" {
+		endStr = *start
+	}
+	to, err := parseTime(endStr, time.Local)
+	if err != nil {
+		return err
+	}
+	if isDate(endStr) {
+		to = to.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	if to.Before(from) {
+		return fmt.Errorf("结束时间早于开始时间")
+	}
+	fmt.Fprintf(out, "min: %s\nmax: %s\n", formatId(ig.MinIdAt(from), enc), formatId(ig.MaxIdAt(to), enc))
+	return nil
+}
+
+// parseTime 解析 RFC3339 或 2006-01-02 格式时间, 后者按 {loc} 时区解析
+func parseTime(s string, loc *time.Location) (time.Time, error) {
+	if isDate(s) {
+		return time.ParseInLocation(time.DateOnly, s, loc)
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("时间 %q 格式错误, 应为 RFC3339 或 2006-01-02", s)
+	}
+	return t, nil
+}
+
+func isDate(s string) bool {
+	return len(s) == len(time.DateOnly)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	var out bytes.Buffer
+	if err := run([]string{"gen", "-n", "3", "-format", "b36", "-worker", "7"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	ids := strings.Fields(out.String())
+	if len(ids) != 3 {
+		t.Fatal("生成数量错误", ids)
+	}
+
+	out.Reset()
+	if err := run([]string{"parse", "-format", "b36", "-worker", "7", ids[0]}, &out); err != nil {
+		t.Fatal(err)
+	}
+	t.Log(out.String())
+	if !strings.Contains(out.String(), "worker: 7") {
+		t.Error("解析结果错误")
+	}
+
+	for _, scheme := range []string{"uuid7", "ulid", "ksuid", "xid"} {
+		out.Reset()
+		if err := run([]string{"gen", "-scheme", scheme}, &out); err != nil {
+			t.Fatal(scheme, err)
+		}
+		id := strings.TrimSpace(out.String())
+		out.Reset()
+		if err := run([]string{"parse", id}, &out); err != nil {
+			t.Fatal(scheme, err)
+		}
+		if !strings.Contains(out.String(), "scheme: "+scheme) {
+			t.Error("类型识别错误", scheme, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := run([]string{"range", "-start", "2025-01-01T00:00:00Z", "-end", "2025-01-02T00:00:00Z"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	t.Log(out.String())
+	if err := run([]string{"range", "-start", "2025-01-02", "-end", "2025-01-01"}, &out); err == nil {
+		t.Error("结束时间早于开始时间理应报错")
+	}
+}
+
+func TestEpochDate(t *testing.T) {
+	// 非 UTC 时区下, 日期形式的 -epoch 与默认起始点一致 (UTC)
+	local := time.Local
+	time.Local = time.FixedZone("UTC+8", 8*3600)
+	defer func() { time.Local = local }()
+
+	var out bytes.Buffer
+	if err := run([]string{"gen"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	id := strings.TrimSpace(out.String())
+	var def, dated bytes.Buffer
+	if err := run([]string{"parse", id}, &def); err != nil {
+		t.Fatal(err)
+	}
+	if err := run([]string{"parse", "-epoch", "2024-01-01", id}, &dated); err != nil {
+		t.Fatal(err)
+	}
+	if def.String() != dated.String() {
+		t.Error("-epoch 2024-01-01 理应与默认起始点一致", def.String(), dated.String())
+	}
+}
@@ -13,8 +13,8 @@ require (
 require golang.org/x/sys v0.33.0 // indirect
 
 require (
-	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0 // indirect
+	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0
 	golang.org/x/crypto v0.38.0
-	golang.org/x/image v0.13.0 // indirect
+	golang.org/x/image v0.13.0
 	golang.org/x/net v0.40.0
 )
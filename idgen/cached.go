@@ -0,0 +1,216 @@
+package idgen
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrBufferExhausted 缓存ID已耗尽
+var ErrBufferExhausted = errors.New("idgen: cached id buffer exhausted")
+
+// RejectPolicy 缓存ID耗尽时的处理策略
+type RejectPolicy int
+
+const (
+	RejectError RejectPolicy = iota //直接返回 ErrBufferExhausted
+	RejectWait                      //阻塞等待填充
+)
+
+// CachedOption 缓存ID生成器选项参数
+type CachedOption struct {
+	BoostPower       int           //缓冲大小 = 每时间单位ID数 << BoostPower, 默认3
+	PaddingFactor    int           //剩余百分比低于此值时触发填充, 范围1-99, 默认50
+	ScheduleInterval time.Duration //定时填充间隔, 默认0不启用
+	RejectPolicy     RejectPolicy  //缓存ID耗尽时的处理策略, 默认 RejectError
+}
+
+// CachedGenerator 缓存ID生成器 (百度 CachedUidGenerator 方案)
+//
+// 后台预先生成ID填入环形缓冲, 取ID时直接从缓冲读取, 适合对延迟敏感的热点路径;
+// 填充时不读取时钟而是递增借用未来时间单位, 每次填满整个时间单位的全部序列号,
+// 因此ID中的时间仅代表趋势, 不代表真实生成时间
+//
+// 参考: https://github.com/baidu/uid-generator
+type CachedGenerator struct {
+	ig       *IdGenerator
+	lastTick int64 //最后填充的时间戳 (仅填充协程访问)
+
+	buffer    chan uint64   //环形缓冲
+	threshold int           //剩余ID数低于此值时触发填充
+	reject    RejectPolicy  //缓存ID耗尽时的处理策略
+	padding   chan struct{} //填充信号
+	done      chan struct{} //关闭信号
+
+	mu     sync.Mutex
+	padErr error         //填充错误 (如超出时间戳期限, 持久化失败, 租约丢失), 出现后不再填充
+	failed chan struct{} //填充错误通知, 唤醒阻塞等待的取ID方
+	once   sync.Once
+	wg     sync.WaitGroup
+}
+
+// NewCachedGenerator 创建缓存ID生成器
+//   - {ig} 雪花算法ID生成器, 沿用其位布局/起始点/机器码/状态存储/租约, 推荐使用 LayoutBaidu 布局
+//   - {opt} 可选项参数
+//
+// 注: 创建后不可再直接用 {ig} 生成ID, Close 后可以 (尚未追上的借用时间按时钟回拨处理);
+// 不再使用时需调用 Close 停止后台填充
+func NewCachedGenerator(ig *IdGenerator, opt ...*CachedOption) *CachedGenerator {
+	var currOpt = CachedOption{}
+	if len(opt) != 0 {
+		currOpt = *opt[0]
+	}
+	if currOpt.BoostPower <= 0 {
+		currOpt.BoostPower = 3
+	}
+	if currOpt.PaddingFactor <= 0 || currOpt.PaddingFactor >= 100 {
+		currOpt.PaddingFactor = 50
+	}
+
+	size := int(ig.layout.MaxSequence()+1) << currOpt.BoostPower
+	ig.mu.Lock()
+	lastTick := max(ig.lastTick(), ig.layout.toTick(ig.clock())-1)
+	ig.mu.Unlock()
+
+	cg := &CachedGenerator{
+		ig:        ig,
+		lastTick:  lastTick,
+		buffer:    make(chan uint64, size),
+		threshold: size * currOpt.PaddingFactor / 100,
+		reject:    currOpt.RejectPolicy,
+		padding:   make(chan struct{}, 1),
+		done:      make(chan struct{}),
+		failed:    make(chan struct{}),
+	}
+	cg.pad()
+
+	cg.wg.Add(1)
+	go cg.run(currOpt.ScheduleInterval)
+	return cg
+}
+
+// Next 取ID
+func (cg *CachedGenerator) Next() (uint64, error) {
+	select {
+	case id := <-cg.buffer:
+		if len(cg.buffer) < cg.threshold {
+			cg.triggerPadding()
+		}
+		return id, nil
+	default:
+	}
+
+	cg.triggerPadding()
+	if err := cg.paddingErr(); err != nil {
+		return 0, err
+	}
+	if cg.reject == RejectError {
+		return 0, ErrBufferExhausted
+	}
+	select {
+	case id := <-cg.buffer:
+		return id, nil
+	case <-cg.failed:
+		// 填充停止前已填入的ID仍可使用
+		select {
+		case id := <-cg.buffer:
+			return id, nil
+		default:
+		}
+		return 0, cg.paddingErr()
+	case <-cg.done:
+		return 0, ErrBufferExhausted
+	}
+}
+
+// Available 当前缓存可用ID数
+func (cg *CachedGenerator) Available() int {
+	return len(cg.buffer)
+}
+
+// Close 停止后台填充, 并将借用的时间同步回原生成器
+func (cg *CachedGenerator) Close() {
+	cg.once.Do(func() {
+		close(cg.done)
+		cg.wg.Wait()
+
+		cg.ig.mu.Lock()
+		defer cg.ig.mu.Unlock()
+		cg.ig.exhaustTo(cg.lastTick)
+	})
+}
+
+func (cg *CachedGenerator) triggerPadding() {
+	select {
+	case cg.padding <- struct{}{}:
+	default:
+	}
+}
+
+func (cg *CachedGenerator) paddingErr() error {
+	cg.mu.Lock()
+	defer cg.mu.Unlock()
+	return cg.padErr
+}
+
+// fail 记录填充错误并唤醒等待方
+func (cg *CachedGenerator) fail(err error) {
+	cg.mu.Lock()
+	defer cg.mu.Unlock()
+	if cg.padErr == nil {
+		cg.padErr = err
+		close(cg.failed)
+	}
+}
+
+func (cg *CachedGenerator) run(interval time.Duration) {
+	defer cg.wg.Done()
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-cg.done:
+			return
+		case <-cg.padding:
+		case <-tick:
+		}
+		cg.pad()
+	}
+}
+
+// pad 填充缓冲, 每次填满一个时间单位的全部序列号, 直至剩余空间不足一个时间单位
+//
+// 与 IdGenerator 生成ID相同, 填充前检查租约并持久化高水位 (设置了 StateStore 时), 计入已生成ID数
+func (cg *CachedGenerator) pad() {
+	if cg.paddingErr() != nil {
+		return
+	}
+	l := cg.ig.layout
+	perTick := int(l.MaxSequence() + 1)
+	for cap(cg.buffer)-len(cg.buffer) >= perTick {
+		tick := cg.lastTick + 1
+		diff := uint64(tick - cg.ig.epoch)
+		if diff > bitsMax(l.TimeBits) {
+			cg.fail(fmt.Errorf("%w: 起始时间 epoch 范围应为 [0, %d]", ErrTimeOverflow, bitsMax(l.TimeBits)-1))
+			return
+		}
+		if err := cg.ig.leaseErr(); err != nil {
+			cg.fail(err)
+			return
+		}
+		if err := cg.ig.ensureReserved(tick); err != nil {
+			cg.fail(err)
+			return
+		}
+		for seq := uint64(0); seq <= l.MaxSequence(); seq++ {
+			cg.buffer <- cg.ig.compose(diff, 0, seq)
+		}
+		cg.ig.generated.Add(uint64(perTick))
+		cg.lastTick = tick
+	}
+}
@@ -0,0 +1,158 @@
+package idgen_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ackcoder/go-mods/idgen"
+)
+
+func TestCachedGenerator(t *testing.T) {
+	layout := idgen.Layout{TimeBits: 41, TimeUnit: time.Millisecond, WorkerBits: 10, SequenceBits: 8}
+	ig := idgen.NewWithLayout(layout, 0, 5)
+	cg := idgen.NewCachedGenerator(ig, &idgen.CachedOption{BoostPower: 2, RejectPolicy: idgen.RejectWait})
+	if cg.Available() != 256<<2 {
+		t.Fatal("初始应填满缓冲", cg.Available())
+	}
+
+	var last uint64
+	for i := 0; i < 10000; i++ {
+		v, err := cg.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v <= last {
+			t.Fatal("id 应单调递增", v, last)
+		}
+		last = v
+	}
+	if info := ig.Parse(last); info.WorkerId != 5 {
+		t.Error("程序ID 错误", info)
+	}
+	cg.Close()
+
+	// 关闭后原生成器继续生成
+	ig.SetRollbackPolicy(idgen.RollbackBorrow(time.Minute))
+	if v := ig.GenNum(); v <= last {
+		t.Error("id 应单调递增", v, last)
+	}
+}
+
+func TestCachedGenerator_Reject(t *testing.T) {
+	layout := idgen.Layout{TimeBits: 41, TimeUnit: time.Millisecond, WorkerBits: 10, SequenceBits: 4}
+	cg := idgen.NewCachedGenerator(idgen.NewWithLayout(layout, 0, 1), &idgen.CachedOption{BoostPower: 1})
+	defer cg.Close()
+
+	var err error
+	for i := 0; i < 10000 && err == nil; i++ {
+		_, err = cg.Next()
+	}
+	if err != nil && !errors.Is(err, idgen.ErrBufferExhausted) {
+		t.Error(err)
+	}
+	t.Log("耗尽时", err)
+}
+
+// failingStateStore 保存 {ok} 次后失败
+type failingStateStore struct {
+	mu   sync.Mutex
+	ok   int
+	mark time.Time
+}
+
+func (s *failingStateStore) Load() (time.Time, error) { return time.Time{}, nil }
+
+func (s *failingStateStore) Save(t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ok <= 0 {
+		return errors.New("disk full")
+	}
+	s.ok--
+	s.mark = t
+	return nil
+}
+
+func TestCachedGenerator_StateStore(t *testing.T) {
+	layout := idgen.Layout{TimeBits: 41, TimeUnit: time.Millisecond, WorkerBits: 10, SequenceBits: 4}
+	ig := idgen.NewWithLayout(layout, 0, 1)
+	store := &failingStateStore{ok: 20}
+	if err := ig.SetStateStore(store, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	cg := idgen.NewCachedGenerator(ig, &idgen.CachedOption{BoostPower: 1, RejectPolicy: idgen.RejectWait})
+	defer cg.Close()
+
+	// 持久化失败后等待中的取ID方应被唤醒并返回错误, 而不是永久阻塞
+	done := make(chan error, 1)
+	var last uint64
+	go func() {
+		for {
+			v, err := cg.Next()
+			if err != nil {
+				done <- err
+				return
+			}
+			last = v
+		}
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, idgen.ErrStatePersist) {
+			t.Error("理应返回持久化错误", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("填充停止后取ID不应永久阻塞")
+	}
+
+	// 已发放的ID均在持久化的高水位之内
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if info := ig.Parse(last); store.mark.Before(info.Time) {
+		t.Error("高水位应不早于已发放ID的时间", store.mark, info.Time)
+	}
+	if st := ig.Stats(); st.Generated == 0 {
+		t.Error("填充的ID应计入统计", st)
+	}
+}
+
+func BenchmarkCachedGenerator(b *testing.B) {
+	cg := idgen.NewCachedGenerator(idgen.NewWithLayout(idgen.LayoutBaidu, 0, 1), &idgen.CachedOption{RejectPolicy: idgen.RejectWait})
+	defer cg.Close()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = cg.Next()
+	}
+}
+
+func TestCachedGenerator_LockFree(t *testing.T) {
+	ig := idgen.NewWithLayout(idgen.LayoutBaidu, 0, 1).SetLockFree(true)
+	seen := make(map[uint64]bool)
+	add := func(v uint64) {
+		if seen[v] {
+			t.Fatal("id 重复", v)
+		}
+		seen[v] = true
+	}
+	for i := 0; i < 100; i++ {
+		add(ig.GenNum())
+	}
+
+	cg := idgen.NewCachedGenerator(ig, &idgen.CachedOption{BoostPower: 1})
+	for i := 0; i < 1000; i++ {
+		v, err := cg.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		add(v)
+	}
+	cg.Close()
+
+	// 关闭后无锁模式的原生成器不应生成缓冲中已填充的ID
+	ig.SetRollbackPolicy(idgen.RollbackBorrow(time.Minute))
+	for i := 0; i < 100; i++ {
+		add(ig.GenNum())
+	}
+}
@@ -0,0 +1,196 @@
+package idgen
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// ErrChecksum 校验字符不匹配
+var ErrChecksum = errors.New("idgen: checksum mismatch")
+
+const (
+	base16Alphabet = "0123456789abcdef"
+	base36Alphabet = "0123456789abcdefghijklmnopqrstuvwxyz"
+	base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+)
+
+var (
+	// Base16 小写十六进制, 与 GenB16 输出一致
+	Base16 = mustEncoding(base16Alphabet, true)
+	// Base36 小写36进制, 与 GenB36 输出一致
+	Base36 = mustEncoding(base36Alphabet, true)
+	// Base32Crockford Crockford Base32, 解码时不区分大小写, 兼容 I/L->1, O->0 并忽略 "-"
+	Base32Crockford = mustEncoding(crockfordAlphabet, true)
+	// Base58 比特币 Base58 字符表 (去除 0 O I l)
+	Base58 = mustEncoding(base58Alphabet, false)
+	// Base62 数字+大写+小写字母
+	Base62 = mustEncoding(base62Alphabet, false)
+)
+
+// Encoding uint64 ID 字串编码
+//
+// 预设有 Base16, Base36, Base32Crockford, Base58, Base62, 也可用 NewEncoding 自定义字符表;
+// 预设字符表均按 ASCII 升序排列, 配合 WithFixedWidth 时编码结果的字典序与数值大小一致
+type Encoding struct {
+	alphabet  string
+	index     *[256]byte
+	width     int  //uint64 最大值编码后的位数
+	fixed     bool //是否定长输出 (高位以首字符补齐)
+	check     bool //是否追加校验字符
+	crockford bool
+}
+
+// NewEncoding 创建自定义字符表编码
+//   - {alphabet} 字符表, 2-64 个不重复的 ASCII 字符
+//
+// 注: 字符表需按 ASCII 升序排列, 定长输出才可按字典序排序
+func NewEncoding(alphabet string) (*Encoding, error) {
+	return newEncoding(alphabet, false)
+}
+
+func newEncoding(alphabet string, foldCase bool) (*Encoding, error) {
+	if len(alphabet) < 2 || len(alphabet) > 64 {
+		return nil, fmt.Errorf("idgen: 字符表长度应为 [2, 64], 实际 %d", len(alphabet))
+	}
+	seen := make(map[byte]struct{}, len(alphabet))
+	for i := 0; i < len(alphabet); i++ {
+		c := alphabet[i]
+		if c >= 0x80 {
+			return nil, fmt.Errorf("idgen: 字符表只能包含 ASCII 字符")
+		}
+		if _, ok := seen[c]; ok {
+			return nil, fmt.Errorf("idgen: 字符表存在重复字符 %q", c)
+		}
+		seen[c] = struct{}{}
+	}
+	e := &Encoding{
+		alphabet:  alphabet,
+		index:     alphabetIndex(alphabet, foldCase),
+		crockford: alphabet == crockfordAlphabet,
+	}
+	if e.crockford {
+		e.index = crockfordIndex
+	}
+	e.width = len(e.encode(1<<64 - 1))
+	return e, nil
+}
+
+func mustEncoding(alphabet string, foldCase bool) *Encoding {
+	e, err := newEncoding(alphabet, foldCase)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// WithFixedWidth 返回定长输出的编码副本 (高位以字符表首字符补齐)
+func (e *Encoding) WithFixedWidth() *Encoding {
+	cp := *e
+	cp.fixed = true
+	return &cp
+}
+
+// WithCheck 返回末尾追加一位校验字符的编码副本
+//
+// 校验算法为 Luhn mod N, 可发现任意单个字符错误与绝大多数相邻字符颠倒, 适合人工抄录/口述的场景
+func (e *Encoding) WithCheck() *Encoding {
+	cp := *e
+	cp.check = true
+	return &cp
+}
+
+// Encode 编码
+func (e *Encoding) Encode(id uint64) string {
+	s := e.encode(id)
+	if e.fixed && len(s) < e.width {
+		s = strings.Repeat(e.alphabet[:1], e.width-len(s)) + s
+	}
+	if e.check {
+		s += string(e.alphabet[e.checksum(s, false)])
+	}
+	return s
+}
+
+// Decode 解码
+func (e *Encoding) Decode(s string) (uint64, error) {
+	if e.crockford {
+		s = strings.ReplaceAll(s, "-", "")
+	}
+	if e.check {
+		if len(s) < 2 {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidFormat, s)
+		}
+		for i := 0; i < len(s); i++ {
+			if e.index[s[i]] == 0xFF {
+				return 0, fmt.Errorf("%w: %q", ErrInvalidFormat, s)
+			}
+		}
+		if e.checksum(s, true) != 0 {
+			return 0, fmt.Errorf("%w: %q", ErrChecksum, s)
+		}
+		s = s[:len(s)-1]
+	}
+	if s == "" {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidFormat, s)
+	}
+
+	base := uint64(len(e.alphabet))
+	var id uint64
+	for i := 0; i < len(s); i++ {
+		v := e.index[s[i]]
+		if v == 0xFF {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidFormat, s)
+		}
+		hi, lo := bits.Mul64(id, base)
+		lo, carry := bits.Add64(lo, uint64(v), 0)
+		if hi != 0 || carry != 0 {
+			return 0, fmt.Errorf("%w: %q 超出 uint64 范围", ErrInvalidFormat, s)
+		}
+		id = lo
+	}
+	return id, nil
+}
+
+func (e *Encoding) encode(id uint64) string {
+	base := uint64(len(e.alphabet))
+	var buf [64]byte
+	i := len(buf)
+	for {
+		i--
+		buf[i] = e.alphabet[id%base]
+		id /= base
+		if id == 0 {
+			break
+		}
+	}
+	return string(buf[i:])
+}
+
+// checksum Luhn mod N 校验
+//   - {withCheck} {s} 是否已含校验字符, 是则返回 0 表示校验通过, 否则返回校验字符序号
+func (e *Encoding) checksum(s string, withCheck bool) int {
+	n := len(e.alphabet)
+	factor, sum := 2, 0
+	if withCheck {
+		factor = 1
+	}
+	for i := len(s) - 1; i >= 0; i-- {
+		addend := factor * int(e.index[s[i]])
+		factor = 3 - factor
+		sum += addend/n + addend%n
+	}
+	if withCheck {
+		return sum % n
+	}
+	return (n - sum%n) % n
+}
+
+// crockfordIndexWithAliases Crockford Base32 反查索引, 兼容易混淆字符
+func crockfordIndexWithAliases() *[256]byte {
+	index := alphabetIndex(crockfordAlphabet, true)
+	index['I'], index['i'], index['L'], index['l'] = 1, 1, 1, 1
+	index['O'], index['o'] = 0, 0
+	return index
+}
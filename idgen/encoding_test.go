@@ -0,0 +1,104 @@
+package idgen_test
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/ackcoder/go-mods/idgen"
+)
+
+func TestEncoding(t *testing.T) {
+	custom, err := idgen.NewEncoding("ACGT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = idgen.NewEncoding("AAB"); err == nil {
+		t.Error("重复字符理应报错")
+	}
+
+	encs := map[string]*idgen.Encoding{
+		"Base16":          idgen.Base16,
+		"Base36":          idgen.Base36,
+		"Base32Crockford": idgen.Base32Crockford,
+		"Base58":          idgen.Base58,
+		"Base62":          idgen.Base62,
+		"Custom":          custom,
+	}
+	nums := []uint64{0, 1, 57, 4096, 370565212555841577, math.MaxUint64}
+	for name, enc := range encs {
+		t.Run(name, func(t *testing.T) {
+			for _, e := range []*idgen.Encoding{enc, enc.WithFixedWidth(), enc.WithCheck(), enc.WithFixedWidth().WithCheck()} {
+				for _, n := range nums {
+					s := e.Encode(n)
+					v, err := e.Decode(s)
+					if err != nil || v != n {
+						t.Fatalf("编解码不一致 %d -> %s -> %d, %v", n, s, v, err)
+					}
+				}
+			}
+
+			// 定长输出字典序与数值大小一致
+			fixed := enc.WithFixedWidth()
+			strs := make([]string, len(nums))
+			for i, n := range nums {
+				strs[i] = fixed.Encode(n)
+			}
+			if !sort.StringsAreSorted(strs) {
+				t.Error("定长输出应可排序", strs)
+			}
+		})
+	}
+
+	if idgen.Base36.Encode(370565212555841577) != strconv.FormatUint(370565212555841577, 36) {
+		t.Error("Base36 应与 GenB36 一致")
+	}
+	if _, err := idgen.Base62.Decode("zzzzzzzzzzzz"); err == nil {
+		t.Error("超出 uint64 理应报错")
+	}
+}
+
+func TestEncoding_Check(t *testing.T) {
+	enc := idgen.Base32Crockford.WithCheck()
+	s := enc.Encode(370565212555841577)
+	t.Log(s)
+
+	// 单个字符错误
+	for i := 0; i < len(s); i++ {
+		b := []byte(s)
+		if b[i] == 'X' {
+			b[i] = 'Y'
+		} else {
+			b[i] = 'X'
+		}
+		if _, err := enc.Decode(string(b)); !errors.Is(err, idgen.ErrChecksum) {
+			t.Error("单字符错误理应校验失败", string(b), err)
+		}
+	}
+	// 相邻字符颠倒
+	b := []byte(s)
+	for i := 0; i+1 < len(b); i++ {
+		if b[i] == b[i+1] {
+			continue
+		}
+		b[i], b[i+1] = b[i+1], b[i]
+		if _, err := enc.Decode(string(b)); err == nil {
+			t.Log("相邻颠倒未发现", string(b))
+		}
+		b[i], b[i+1] = b[i+1], b[i]
+	}
+	// 口述易混淆字符与分隔符
+	v1, _ := enc.Decode(s)
+	v2, err := enc.Decode(s[:4] + "-" + s[4:])
+	if err != nil || v1 != v2 {
+		t.Error("应忽略分隔符", err)
+	}
+
+	id := idgen.New(1, 1)
+	encStr, numStr := id.GenEnc(idgen.Base62)
+	if v, err := idgen.Base62.Decode(encStr); err != nil || strconv.FormatUint(v, 10) != numStr {
+		t.Error("GenEnc 编码错误", encStr, numStr, err)
+	}
+}
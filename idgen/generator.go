@@ -0,0 +1,116 @@
+package idgen
+
+import (
+	"crypto/rand"
+	"errors"
+	"time"
+)
+
+// Identifier 通用唯一ID
+type Identifier interface {
+	// String 标准字串形式
+	String() string
+	// Bytes 二进制形式
+	Bytes() []byte
+	// Time 生成时间, 不含时间信息的ID (如 UUIDv4) 返回零值
+	Time() time.Time
+}
+
+// Generator 通用唯一ID生成器
+//
+// 实现有: UUIDv4Generator, UUIDv7Generator, ULIDGenerator, KSUIDGenerator, XIDGenerator
+type Generator interface {
+	// Next 生成ID
+	Next() (Identifier, error)
+	// Parse 解析标准字串形式的ID
+	Parse(s string) (Identifier, error)
+}
+
+var (
+	// ErrInvalidFormat ID 字串格式错误
+	ErrInvalidFormat = errors.New("idgen: invalid id format")
+	// ErrMonotonicOverflow 同一时间单位内单调递增随机数溢出
+	ErrMonotonicOverflow = errors.New("idgen: monotonic entropy overflow")
+)
+
+// randRead 读取密码学随机数
+func randRead(b []byte) error {
+	_, err := rand.Read(b)
+	return err
+}
+
+// incrBytes 将字节切片视为大端整数加一, 返回是否溢出
+func incrBytes(b []byte) bool {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i]++
+		if b[i] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// encodeBits 将字节切片视为大端整数, 按每字符 5 位编码为定长 {n} 个字符 (高位补 0)
+func encodeBits(src []byte, n int, alphabet string) string {
+	total := len(src) * 8
+	out := make([]byte, n)
+	for i := 0; i < n; i++ {
+		shift := (n - 1 - i) * 5
+		var v byte
+		for b := 0; b < 5; b++ {
+			if bit := shift + b; bit < total {
+				v |= (src[len(src)-1-bit/8] >> (bit % 8) & 1) << b
+			}
+		}
+		out[i] = alphabet[v]
+	}
+	return string(out)
+}
+
+// decodeBits encodeBits 的逆操作, 溢出 {dst} 长度的高位必须为 0
+func decodeBits(s string, dst []byte, index *[256]byte) error {
+	total := len(dst) * 8
+	for i := range dst {
+		dst[i] = 0
+	}
+	n := len(s)
+	for i := 0; i < n; i++ {
+		v := index[s[i]]
+		if v == 0xFF {
+			return ErrInvalidFormat
+		}
+		shift := (n - 1 - i) * 5
+		for b := 0; b < 5; b++ {
+			if v>>b&1 == 0 {
+				continue
+			}
+			bit := shift + b
+			if bit >= total {
+				return ErrInvalidFormat
+			}
+			dst[len(dst)-1-bit/8] |= 1 << (bit % 8)
+		}
+	}
+	return nil
+}
+
+// alphabetIndex 生成字符表反查索引, 不在字符表内的字符值为 0xFF
+//   - {foldCase} 是否忽略大小写
+func alphabetIndex(alphabet string, foldCase bool) *[256]byte {
+	var index [256]byte
+	for i := range index {
+		index[i] = 0xFF
+	}
+	for i := 0; i < len(alphabet); i++ {
+		c := alphabet[i]
+		index[c] = byte(i)
+		if foldCase {
+			if 'a' <= c && c <= 'z' {
+				index[c-'a'+'A'] = byte(i)
+			} else if 'A' <= c && c <= 'Z' {
+				index[c-'A'+'a'] = byte(i)
+			}
+		}
+	}
+	return &index
+}
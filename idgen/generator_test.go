@@ -0,0 +1,84 @@
+package idgen_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/ackcoder/go-mods/idgen"
+)
+
+func TestGenerator(t *testing.T) {
+	cases := []struct {
+		name     string
+		gen      idgen.Generator
+		length   int
+		hasTime  bool
+		monotone bool
+	}{
+		{"UUIDv4", idgen.NewUUIDv4(), 36, false, false},
+		{"UUIDv7", idgen.NewUUIDv7(), 36, true, true},
+		{"ULID", idgen.NewULID(), 26, true, true},
+		{"KSUID", idgen.NewKSUID(), 27, true, false},
+		{"XID", idgen.NewXID(), 20, true, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var last string
+			seen := make(map[string]struct{})
+			for i := 0; i < 5000; i++ {
+				id, err := c.gen.Next()
+				if err != nil {
+					t.Fatal(err)
+				}
+				s := id.String()
+				if len(s) != c.length {
+					t.Fatalf("长度应为 %d: %s", c.length, s)
+				}
+				if _, ok := seen[s]; ok {
+					t.Fatal("id 重复: ", s)
+				}
+				seen[s] = struct{}{}
+				if c.monotone && s <= last {
+					t.Fatalf("id 应单调递增: %s <= %s", s, last)
+				}
+				last = s
+
+				parsed, err := c.gen.Parse(s)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(parsed.Bytes(), id.Bytes()) {
+					t.Fatal("解析结果不一致: ", s)
+				}
+				if c.hasTime && time.Since(id.Time()).Abs() > 2*time.Second {
+					t.Fatal("时间解析错误: ", id.Time())
+				}
+			}
+			t.Log(last)
+			if _, err := c.gen.Parse("!" + last[1:]); err == nil {
+				t.Error("非法字串理应解析失败")
+			}
+		})
+	}
+}
+
+func TestParseKnownIds(t *testing.T) {
+	// 取自各规范文档示例
+	if u, err := idgen.ParseUUID("017F22E2-79B0-7CC3-98C4-DC0C0C07398F"); err != nil || u.Version() != 7 ||
+		u.Time().UnixMilli() != 0x017F22E279B0 {
+		t.Error("UUIDv7 解析错误", u, err)
+	}
+	if u, err := idgen.ParseULID("01ARZ3NDEKTSV4RRFFQ69G5FAV"); err != nil || u.Time().UnixMilli() != 1469922850259 {
+		t.Error("ULID 解析错误", u, err)
+	}
+	if k, err := idgen.ParseKSUID("0ujtsYcgvSTl8PAuAdqWYSMnLOv"); err != nil || k.Time().Unix() != 1507608047 {
+		t.Error("KSUID 解析错误", k, err)
+	}
+	if x, err := idgen.ParseXID("9m4e2mr0ui3e8a215n4g"); err != nil || x.Time().Unix() != 1300816219 || x.Counter() != 0x412dc9 {
+		t.Error("XID 解析错误", x, err)
+	}
+	if _, err := idgen.ParseULID("81ARZ3NDEKTSV4RRFFQ69G5FAV"); err == nil {
+		t.Error("ULID 溢出理应解析失败")
+	}
+}
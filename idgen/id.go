@@ -0,0 +1,112 @@
+package idgen
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ID 雪花算法ID
+//
+// JSON 序列化为字串 (避免 JavaScript 等前端超出 2^53 精度丢失), 反序列化兼容字串与数值;
+// 数据库存取为 BIGINT (int64), 雪花算法ID 最高位恒为 0, 不会溢出
+//
+//	注: ID 不记录位布局与起始点, Time 按默认布局与起始点计算; 其他生成器的ID 使用 TimeFor 或 ig.Parse(id.Uint64())
+type ID uint64
+
+// ParseID 解析10进制位字串ID
+func ParseID(s string) (ID, error) {
+	v, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("idgen: 解析ID %q 失败: %w", s, err)
+	}
+	return ID(v), nil
+}
+
+// String 10进制位字串
+func (id ID) String() string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// Uint64 数值形式
+func (id ID) Uint64() uint64 {
+	return uint64(id)
+}
+
+// Time 生成时间
+//
+//	注: 按默认 LayoutTwitter 布局与默认起始点 (2024-01-01 UTC) 计算, 仅适用于 New/NewE 创建的生成器;
+//	其他布局或起始点请使用 TimeFor
+func (id ID) Time() time.Time {
+	l := LayoutTwitter
+	return l.fromTick(l.toTick(defaultEpoch) + int64(uint64(id)>>l.timeShift()))
+}
+
+// TimeFor 按生成器 {ig} 的位布局与起始点计算生成时间, 同 ig.Parse(id.Uint64()).Time
+func (id ID) TimeFor(ig *IdGenerator) time.Time {
+	return ig.Parse(uint64(id)).Time
+}
+
+func (id ID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+func (id *ID) UnmarshalText(b []byte) error {
+	v, err := ParseID(string(b))
+	if err != nil {
+		return err
+	}
+	*id = v
+	return nil
+}
+
+func (id ID) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + id.String() + `"`), nil
+}
+
+func (id *ID) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if s == "null" {
+		return nil
+	}
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = s[1 : len(s)-1]
+	}
+	return id.UnmarshalText([]byte(s))
+}
+
+// Value 实现 driver.Valuer
+func (id ID) Value() (driver.Value, error) {
+	if uint64(id) > 1<<63-1 {
+		return nil, fmt.Errorf("idgen: ID %d 超出 int64 范围", uint64(id))
+	}
+	return int64(id), nil
+}
+
+// Scan 实现 sql.Scanner
+func (id *ID) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*id = 0
+	case int64:
+		if v < 0 {
+			return fmt.Errorf("idgen: 无法将负数 %d 转为ID", v)
+		}
+		*id = ID(v)
+	case uint64:
+		*id = ID(v)
+	case []byte:
+		return id.UnmarshalText(v)
+	case string:
+		return id.UnmarshalText([]byte(v))
+	default:
+		return fmt.Errorf("idgen: 无法将 %T 转为ID", src)
+	}
+	return nil
+}
+
+// GenID 生成雪花算法ID (ID 类型)
+func (ig *IdGenerator) GenID() ID {
+	return ID(ig.genId())
+}
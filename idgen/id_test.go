@@ -0,0 +1,56 @@
+package idgen_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ackcoder/go-mods/idgen"
+)
+
+func TestID(t *testing.T) {
+	ig := idgen.New(1, 1)
+	id := ig.GenID()
+	if time.Since(id.Time()).Abs() > time.Second {
+		t.Error("时间解析错误", id.Time())
+	}
+
+	// 自定义布局与起始点需使用对应生成器解析
+	custom := idgen.NewWithLayout(idgen.LayoutSonyflake, 0, 1, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	if tm := custom.GenID().TimeFor(custom); time.Since(tm).Abs() > time.Second {
+		t.Error("自定义布局时间解析错误", tm)
+	}
+
+	type order struct {
+		Id   idgen.ID   `json:"id"`
+		Refs []idgen.ID `json:"refs"`
+	}
+	b, err := json.Marshal(order{Id: id, Refs: []idgen.ID{1, 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(string(b))
+	if string(b) != `{"id":"`+id.String()+`","refs":["1","2"]}` {
+		t.Error("JSON 应序列化为字串", string(b))
+	}
+
+	var o order
+	if err = json.Unmarshal([]byte(`{"id":`+id.String()+`,"refs":["1",2]}`), &o); err != nil {
+		t.Fatal(err)
+	}
+	if o.Id != id || len(o.Refs) != 2 || o.Refs[1] != 2 {
+		t.Error("JSON 反序列化错误", o)
+	}
+
+	var scanned idgen.ID
+	v, _ := id.Value()
+	if err = scanned.Scan(v); err != nil || scanned != id {
+		t.Error("数据库存取错误", scanned, err)
+	}
+	if err = scanned.Scan([]byte("12")); err != nil || scanned != 12 {
+		t.Error("数据库存取错误", scanned, err)
+	}
+	if err = scanned.Scan(int64(-1)); err == nil {
+		t.Error("负数理应报错")
+	}
+}
@@ -1,6 +1,8 @@
 package idgen_test
 
 import (
+	"errors"
+	"fmt"
 	"sync"
 	"testing"
 	"time"
@@ -49,6 +51,231 @@ func TestIdGenerator(t *testing.T) {
 	}
 }
 
+func TestIdGenerator_Layout(t *testing.T) {
+	bad := idgen.Layout{TimeBits: 41, TimeUnit: time.Millisecond, WorkerBits: 12, SequenceBits: 12}
+	if err := bad.Validate(); !errors.Is(err, idgen.ErrInvalidLayout) {
+		t.Error("超过 63 位理应校验失败", err)
+	}
+
+	for _, layout := range []idgen.Layout{idgen.LayoutTwitter, idgen.LayoutSonyflake, idgen.LayoutBaidu} {
+		if err := layout.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		t.Log("节点数", (layout.MaxDataCenterId()+1)*(layout.MaxWorkerId()+1), "可用时长", layout.Lifetime())
+
+		id := idgen.NewWithLayout(layout, 0, layout.MaxWorkerId())
+		var checkMap = make(map[uint64]struct{})
+		for i := 0; i < 1000; i++ {
+			v := id.GenNum()
+			if _, ok := checkMap[v]; ok {
+				t.Fatal("id 重复: ", v)
+			}
+			checkMap[v] = struct{}{}
+			if workerId := (v >> layout.SequenceBits) & layout.MaxWorkerId(); workerId != layout.MaxWorkerId() {
+				t.Fatal("程序ID 位置错误: ", workerId)
+			}
+		}
+	}
+}
+
+func TestIdGenerator_Parse(t *testing.T) {
+	id := idgen.New(3, 7)
+	before := time.Now().Truncate(time.Millisecond)
+	v := id.GenNum()
+	after := time.Now()
+
+	info := id.Parse(v)
+	t.Logf("%+v", info)
+	if info.DataCenterId != 3 || info.WorkerId != 7 {
+		t.Error("机器码解析错误", info)
+	}
+	if info.Time.Before(before) || info.Time.After(after) {
+		t.Error("时间解析错误", info.Time)
+	}
+	if info2, err := id.ParseString(id.Gen()); err != nil || info2.WorkerId != 7 {
+		t.Error("字串解析错误", info2, err)
+	}
+	if _, err := id.ParseString("abc"); err == nil {
+		t.Error("非法字串理应解析失败")
+	}
+
+	if min, max := id.MinIdAt(info.Time), id.MaxIdAt(info.Time); v < min || v > max {
+		t.Errorf("id %d 应在 [%d, %d] 范围内", v, min, max)
+	}
+	if id.MaxIdAt(before.Add(-time.Millisecond)) >= v || id.MinIdAt(after.Add(time.Millisecond)) <= v {
+		t.Error("时间范围边界错误")
+	}
+}
+
+func TestIdGenerator_Rollback(t *testing.T) {
+	if _, err := idgen.NewE(1, 32); !errors.Is(err, idgen.ErrInvalidNode) {
+		t.Error("程序ID 超出范围理应报错", err)
+	}
+
+	var mu sync.Mutex
+	now := time.Now()
+	clock := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	setClock := func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(d)
+	}
+
+	t.Run("Fail", func(t *testing.T) {
+		id := idgen.New(1, 1).SetClock(clock).SetRollbackPolicy(idgen.RollbackFail())
+		if _, err := id.GenE(); err != nil {
+			t.Fatal(err)
+		}
+		setClock(-time.Second)
+		defer setClock(time.Second)
+		if _, err := id.GenE(); !errors.Is(err, idgen.ErrClockBackwards) {
+			t.Error("时钟回拨理应报错", err)
+		}
+	})
+
+	t.Run("Borrow", func(t *testing.T) {
+		id := idgen.New(1, 1).SetClock(clock).SetRollbackPolicy(idgen.RollbackBorrow(5 * time.Millisecond))
+		last, _ := id.GenE()
+		setClock(-time.Millisecond)
+		defer setClock(time.Millisecond)
+		// 时钟停滞时借用未来时间, 不会阻塞等待
+		var err error
+		for i := 0; i < 4096*10 && err == nil; i++ {
+			var v uint64
+			if v, err = id.GenE(); err == nil {
+				if v <= last {
+					t.Fatal("id 应单调递增", v, last)
+				}
+				last = v
+			}
+		}
+		if !errors.Is(err, idgen.ErrClockBackwards) {
+			t.Error("超出借用范围理应报错", err)
+		}
+	})
+
+	t.Run("Bits", func(t *testing.T) {
+		layout := idgen.Layout{TimeBits: 41, TimeUnit: time.Millisecond, WorkerBits: 8, RollbackBits: 2, SequenceBits: 12}
+		id := idgen.NewWithLayout(layout, 0, 1).SetClock(clock).SetRollbackPolicy(idgen.RollbackBits())
+		first, _ := id.GenE()
+		setClock(-time.Second)
+		defer setClock(time.Second)
+		v, err := id.GenE()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info := id.Parse(v); info.Rollback != 1 || info.WorkerId != 1 {
+			t.Error("回拨序号解析错误", info)
+		}
+		if v == first {
+			t.Error("id 重复")
+		}
+	})
+
+	t.Run("Wait", func(t *testing.T) {
+		id := idgen.New(1, 1).SetRollbackPolicy(idgen.RollbackWait(10 * time.Millisecond))
+		if _, err := id.GenE(); err != nil {
+			t.Fatal(err)
+		}
+		id.SetClock(func() time.Time { return time.Now().Add(-time.Second) })
+		st := time.Now()
+		if _, err := id.GenE(); !errors.Is(err, idgen.ErrClockBackwards) {
+			t.Error("回拨超出等待时间理应报错", err)
+		}
+		if time.Since(st) > 5*time.Millisecond {
+			t.Error("回拨超出等待时间不应等待")
+		}
+	})
+}
+
+func TestIdGenerator_LockFree(t *testing.T) {
+	id := idgen.New(1, 1).SetLockFree(true)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var checkMap = make(map[uint64]struct{})
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ids := make([]uint64, 0, 5000)
+			for j := 0; j < 5000; j++ {
+				ids = append(ids, id.GenNum())
+			}
+			batch, err := id.GenBatch(5000)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			ids = append(ids, batch...)
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, v := range ids {
+				if _, ok := checkMap[v]; ok {
+					t.Error("id 重复: ", v)
+					return
+				}
+				checkMap[v] = struct{}{}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 切回互斥锁模式后继续递增
+	last := id.GenNum()
+	if v := id.SetLockFree(false).GenNum(); v <= last {
+		t.Error("id 应单调递增", v, last)
+	}
+}
+
+func TestIdGenerator_GenBatch(t *testing.T) {
+	id := idgen.New(1, 1)
+	ids, err := id.GenBatch(10000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 10000 {
+		t.Fatal("数量错误", len(ids))
+	}
+	for i := 1; i < len(ids); i++ {
+		if ids[i] <= ids[i-1] {
+			t.Fatal("id 应单调递增", ids[i], ids[i-1])
+		}
+	}
+	if v := id.GenNum(); v <= ids[len(ids)-1] {
+		t.Error("id 应单调递增", v)
+	}
+
+	if ids, err = id.GenBatch(0); err != nil || ids == nil || len(ids) != 0 {
+		t.Error("数量为 0 理应返回空切片", ids, err)
+	}
+	if _, err = id.GenBatch(-1); err == nil {
+		t.Error("数量为负数理应报错")
+	}
+}
+
+func TestIdGenerator_SetRollbackPolicyConcurrent(t *testing.T) {
+	id := idgen.New(1, 1).SetLockFree(true)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			id.SetRollbackPolicy(idgen.RollbackBorrow(time.Second))
+		}
+	}()
+	for i := 0; i < 1000; i++ {
+		if _, err := id.GenE(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-done
+}
+
 // go test -run='^$' -bench=. -count=1 -benchtime=2s
 func BenchmarkIdGenerator(b *testing.B) {
 	id := idgen.New(1, 2)
@@ -58,3 +285,39 @@ func BenchmarkIdGenerator(b *testing.B) {
 		id.Gen()
 	}
 }
+
+// 宽序列号布局, 避免基准测试受每毫秒 4096 个上限影响
+var benchLayout = idgen.Layout{TimeBits: 41, TimeUnit: time.Millisecond, WorkerBits: 2, SequenceBits: 20}
+
+func BenchmarkIdGenerator_Parallel(b *testing.B) {
+	for _, c := range []struct {
+		name     string
+		layout   idgen.Layout
+		lockFree bool
+	}{
+		{"Mutex", idgen.LayoutTwitter, false},
+		{"LockFree", idgen.LayoutTwitter, true},
+		{"WideMutex", benchLayout, false},
+		{"WideLockFree", benchLayout, true},
+	} {
+		b.Run(c.name, func(b *testing.B) {
+			id := idgen.NewWithLayout(c.layout, 0, 1).SetLockFree(c.lockFree)
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					id.GenNum()
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkIdGenerator_GenBatch(b *testing.B) {
+	for _, lockFree := range []bool{false, true} {
+		b.Run(fmt.Sprintf("LockFree=%v", lockFree), func(b *testing.B) {
+			id := idgen.NewWithLayout(benchLayout, 0, 1).SetLockFree(lockFree)
+			for i := 0; i < b.N; i++ {
+				_, _ = id.GenBatch(100)
+			}
+		})
+	}
+}
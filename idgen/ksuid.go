@@ -0,0 +1,96 @@
+package idgen
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const (
+	// ksuidEpoch KSUID 起始点 (秒), 即 2014-05-13 16:53:20 UTC
+	ksuidEpoch = 1400000000
+	// base62Alphabet KSUID 使用的 Base62 字符表
+	base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+var ksuidMax = new(big.Int).Lsh(big.NewInt(1), 160)
+
+// KSUID 160位 = 32位秒级时间戳 + 128位随机数, 字串形式为27位 Base62
+//
+// 规范: https://github.com/segmentio/ksuid
+type KSUID [20]byte
+
+// ParseKSUID 解析 KSUID 字串
+func ParseKSUID(s string) (KSUID, error) {
+	var k KSUID
+	if len(s) != 27 {
+		return k, fmt.Errorf("%w: ksuid %q", ErrInvalidFormat, s)
+	}
+	n := new(big.Int)
+	base := big.NewInt(62)
+	for i := 0; i < len(s); i++ {
+		v := strings.IndexByte(base62Alphabet, s[i])
+		if v < 0 {
+			return k, fmt.Errorf("%w: ksuid %q", ErrInvalidFormat, s)
+		}
+		n.Mul(n, base).Add(n, big.NewInt(int64(v)))
+	}
+	if n.Cmp(ksuidMax) >= 0 {
+		return k, fmt.Errorf("%w: ksuid %q", ErrInvalidFormat, s)
+	}
+	n.FillBytes(k[:])
+	return k, nil
+}
+
+func (k KSUID) String() string {
+	n := new(big.Int).SetBytes(k[:])
+	out := []byte(strings.Repeat("0", 27))
+	base := big.NewInt(62)
+	mod := new(big.Int)
+	for i := len(out) - 1; i >= 0 && n.Sign() > 0; i-- {
+		n.DivMod(n, base, mod)
+		out[i] = base62Alphabet[mod.Int64()]
+	}
+	return string(out)
+}
+
+func (k KSUID) Bytes() []byte {
+	return k[:]
+}
+
+// Time 生成时间 (秒精度)
+func (k KSUID) Time() time.Time {
+	ts := int64(k[0])<<24 | int64(k[1])<<16 | int64(k[2])<<8 | int64(k[3])
+	return time.Unix(ts+ksuidEpoch, 0)
+}
+
+// ============================================================
+
+// KSUIDGenerator KSUID 生成器
+//
+//	注: 同一秒内的 KSUID 之间无序
+type KSUIDGenerator struct {
+	clock func() time.Time
+}
+
+func NewKSUID() *KSUIDGenerator {
+	return &KSUIDGenerator{clock: time.Now}
+}
+
+func (g *KSUIDGenerator) Next() (Identifier, error) {
+	var k KSUID
+	if err := randRead(k[4:]); err != nil {
+		return nil, err
+	}
+	ts := uint32(g.clock().Unix() - ksuidEpoch)
+	k[0] = byte(ts >> 24)
+	k[1] = byte(ts >> 16)
+	k[2] = byte(ts >> 8)
+	k[3] = byte(ts)
+	return k, nil
+}
+
+func (g *KSUIDGenerator) Parse(s string) (Identifier, error) {
+	return ParseKSUID(s)
+}
@@ -0,0 +1,141 @@
+package idgen
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Layout 雪花算法 ID 位布局
+//
+// ID 固定由高到低按 [符号位 0][时间戳][集群ID][程序ID][回拨序号][序列号] 排列,
+// 各段位数之和不可超过 63 位 (最高位恒为 0, 保证转 int64 时为正数)
+type Layout struct {
+	TimeBits       uint8         //时间戳占用位
+	TimeUnit       time.Duration //时间戳单位, 如 1ms/10ms/1s
+	DataCenterBits uint8         //集群ID占用位, 可为 0
+	WorkerBits     uint8         //程序ID占用位, 可为 0
+	RollbackBits   uint8         //回拨序号占用位, 可为 0 (仅 RollbackBits 策略使用)
+	SequenceBits   uint8         //序列号占用位
+}
+
+var (
+	// LayoutTwitter 经典 Twitter 布局 (默认)
+	//   - 41位毫秒时间戳 约 69 年
+	//   - 5位集群ID + 5位程序ID 共 1024 个节点
+	//   - 12位序列号 每节点每毫秒 4096 个
+	LayoutTwitter = Layout{
+		TimeBits:       timestampBits,
+		TimeUnit:       time.Millisecond,
+		DataCenterBits: dataCenterIdBits,
+		WorkerBits:     workerIdBits,
+		SequenceBits:   sequenceBits,
+	}
+
+	// LayoutSonyflake Sonyflake 布局
+	//   - 39位10毫秒时间戳 约 174 年
+	//   - 16位程序ID 共 65536 个节点
+	//   - 8位序列号 每节点每10毫秒 256 个
+	//
+	// 注: 原版 Sonyflake 为 [时间戳][序列号][机器码] 排列, 此处保持本包统一的 [时间戳][节点][序列号] 排列
+	LayoutSonyflake = Layout{
+		TimeBits:     39,
+		TimeUnit:     10 * time.Millisecond,
+		WorkerBits:   16,
+		SequenceBits: 8,
+	}
+
+	// LayoutBaidu 百度 UidGenerator 默认布局
+	//   - 28位秒级时间戳 约 8.7 年
+	//   - 22位程序ID 共约 420 万个节点 (适合每次启动分配新程序ID)
+	//   - 13位序列号 每节点每秒 8192 个
+	LayoutBaidu = Layout{
+		TimeBits:     28,
+		TimeUnit:     time.Second,
+		WorkerBits:   22,
+		SequenceBits: 13,
+	}
+)
+
+// ErrInvalidLayout 位布局参数无效
+var ErrInvalidLayout = errors.New("idgen: invalid layout")
+
+// Validate 校验位布局参数
+func (l Layout) Validate() error {
+	if l.TimeBits == 0 {
+		return fmt.Errorf("%w: 时间戳占用位不能为 0", ErrInvalidLayout)
+	}
+	if l.SequenceBits == 0 {
+		return fmt.Errorf("%w: 序列号占用位不能为 0", ErrInvalidLayout)
+	}
+	if l.TimeUnit < time.Millisecond {
+		return fmt.Errorf("%w: 时间戳单位不能小于 1ms", ErrInvalidLayout)
+	}
+	if l.TimeUnit%time.Millisecond != 0 {
+		return fmt.Errorf("%w: 时间戳单位应为 1ms 的整数倍", ErrInvalidLayout)
+	}
+	total := int(l.TimeBits) + int(l.DataCenterBits) + int(l.WorkerBits) + int(l.RollbackBits) + int(l.SequenceBits)
+	if total > 63 {
+		return fmt.Errorf("%w: 各段位数之和为 %d, 应不超过 63", ErrInvalidLayout, total)
+	}
+	return nil
+}
+
+// MaxDataCenterId 集群ID 最大值
+func (l Layout) MaxDataCenterId() uint64 {
+	return bitsMax(l.DataCenterBits)
+}
+
+// MaxWorkerId 程序ID 最大值
+func (l Layout) MaxWorkerId() uint64 {
+	return bitsMax(l.WorkerBits)
+}
+
+// MaxSequence 序列号最大值
+func (l Layout) MaxSequence() uint64 {
+	return bitsMax(l.SequenceBits)
+}
+
+// Lifetime 时间戳可用时长 (自起始点算起)
+func (l Layout) Lifetime() time.Duration {
+	ticks := bitsMax(l.TimeBits)
+	if ticks > uint64(1<<63-1)/uint64(l.TimeUnit) {
+		return time.Duration(1<<63 - 1)
+	}
+	return time.Duration(ticks) * l.TimeUnit
+}
+
+// MaxRollback 回拨序号最大值
+func (l Layout) MaxRollback() uint64 {
+	return bitsMax(l.RollbackBits)
+}
+
+func (l Layout) rollbackShift() uint8 {
+	return l.SequenceBits
+}
+
+func (l Layout) workerShift() uint8 {
+	return l.SequenceBits + l.RollbackBits
+}
+
+func (l Layout) dataCenterShift() uint8 {
+	return l.SequenceBits + l.RollbackBits + l.WorkerBits
+}
+
+func (l Layout) timeShift() uint8 {
+	return l.SequenceBits + l.RollbackBits + l.WorkerBits + l.DataCenterBits
+}
+
+// toTick 时间转为时间戳 (单位 TimeUnit)
+func (l Layout) toTick(t time.Time) int64 {
+	return t.UnixMilli() / l.TimeUnit.Milliseconds()
+}
+
+// fromTick 时间戳 (单位 TimeUnit) 转为时间
+func (l Layout) fromTick(tick int64) time.Time {
+	return time.UnixMilli(tick * l.TimeUnit.Milliseconds())
+}
+
+func bitsMax(bits uint8) uint64 {
+	return 1<<bits - 1
+}
@@ -0,0 +1,151 @@
+package idgen
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// SetLockFree 设置是否使用无锁模式 (默认否)
+//
+// 无锁模式将 [时间戳差值][回拨序号][序列号] 打包为一个状态字, 通过原子 CAS 更新,
+// 高并发下避免互斥锁争用; 序列号溢出时让出CPU而非空转读取时钟
+//
+//	注: 应在生成ID前设置, 不要与生成ID并发调用
+func (ig *IdGenerator) SetLockFree(enable bool) *IdGenerator {
+	ig.mu.Lock()
+	defer ig.mu.Unlock()
+	if enable == ig.lockFree.Load() {
+		return ig
+	}
+	l := ig.layout
+	if enable {
+		var state uint64
+		if ig.timestamp >= ig.epoch {
+			state = uint64(ig.timestamp-ig.epoch)<<(l.RollbackBits+l.SequenceBits) |
+				ig.rollbackSeq<<l.rollbackShift() |
+				ig.sequence
+		}
+		ig.state.Store(state)
+	} else {
+		state := ig.state.Load()
+		ig.timestamp = ig.epoch + int64(state>>(l.RollbackBits+l.SequenceBits))
+		ig.rollbackSeq = (state >> l.rollbackShift()) & l.MaxRollback()
+		ig.sequence = state & l.MaxSequence()
+	}
+	ig.lockFree.Store(enable)
+	return ig
+}
+
+// lastTick 最后生成ID的时间戳, 需持有锁 (无锁模式下读取状态字)
+func (ig *IdGenerator) lastTick() int64 {
+	if !ig.lockFree.Load() {
+		return ig.timestamp
+	}
+	l := ig.layout
+	return ig.epoch + int64(ig.state.Load()>>(l.RollbackBits+l.SequenceBits))
+}
+
+// exhaustTo 将最后时间戳推进至不早于 {tick} 并用尽其序列号, 需持有锁
+//
+// 缓存ID生成器关闭时调用, 使原生成器不再生成借用时间内已填充的ID
+func (ig *IdGenerator) exhaustTo(tick int64) {
+	l := ig.layout
+	if !ig.lockFree.Load() {
+		if tick >= ig.timestamp {
+			ig.timestamp, ig.sequence = tick, l.MaxSequence()
+		}
+		return
+	}
+	stateShift := l.RollbackBits + l.SequenceBits
+	for {
+		old := ig.state.Load()
+		if ig.epoch+int64(old>>stateShift) > tick {
+			return
+		}
+		state := uint64(tick-ig.epoch)<<stateShift | old&(l.MaxRollback()<<l.rollbackShift()) | l.MaxSequence()
+		if ig.state.CompareAndSwap(old, state) {
+			return
+		}
+	}
+}
+
+// reserveAtomic 无锁模式预留ID, 逻辑同 reserve
+func (ig *IdGenerator) reserveAtomic(n uint64) (uint64, uint64, error) {
+	l := ig.layout
+	stateShift := l.RollbackBits + l.SequenceBits
+	maxSeq := l.MaxSequence()
+	policy := ig.rollback.Load()
+
+	var deadline time.Time //RollbackWait 等待截止时间
+	var rolledBack, overflowed bool
+	for {
+		old := ig.state.Load()
+		last := ig.epoch + int64(old>>stateShift)
+		rollbackSeq := (old >> l.rollbackShift()) & l.MaxRollback()
+		sequence := old & maxSeq
+
+		now := l.toTick(ig.clock())
+		newTick := now > last
+
+		// 时钟回拨处理
+		if now < last {
+			if !rolledBack {
+				rolledBack = true
+				ig.rollbacks.Add(1)
+			}
+			switch {
+			case policy.mode == rollbackWait:
+				if deadline.IsZero() {
+					deadline = ig.clock().Add(policy.max)
+				}
+				wait := l.fromTick(last).Sub(l.fromTick(now))
+				if ig.clock().Add(wait).After(deadline) {
+					return 0, 0, fmt.Errorf("%w: 最后时间戳 %d, 比较时间戳 %d", ErrClockBackwards, last, now)
+				}
+				time.Sleep(wait)
+				continue
+			case policy.mode == rollbackBorrow && ig.borrowable(last, policy):
+				now = last
+			case policy.mode == rollbackBits && l.RollbackBits != 0:
+				rollbackSeq = (rollbackSeq + 1) & l.MaxRollback()
+				newTick = true
+			default:
+				return 0, 0, fmt.Errorf("%w: 最后时间戳 %d, 比较时间戳 %d", ErrClockBackwards, last, now)
+			}
+		}
+
+		var first uint64 = defaultInitValue
+		if !newTick {
+			if sequence < maxSeq {
+				first = sequence + 1
+			} else if policy.mode == rollbackBorrow && ig.borrowable(last+1, policy) {
+				now = last + 1
+			} else if policy.mode == rollbackBorrow && l.toTick(ig.clock()) < last {
+				return 0, 0, fmt.Errorf("%w: 借用时间超出上限 %s", ErrClockBackwards, policy.max)
+			} else {
+				// 序列号溢出、让出CPU后重试至下一时间单位
+				if !overflowed {
+					overflowed = true
+					ig.overflows.Add(1)
+				}
+				runtime.Gosched()
+				continue
+			}
+		}
+
+		diff := uint64(now - ig.epoch)
+		if diff > bitsMax(l.TimeBits) {
+			return 0, 0, fmt.Errorf("%w: 起始时间 epoch 范围应为 [0, %d]", ErrTimeOverflow, bitsMax(l.TimeBits)-1)
+		}
+		if err := ig.ensureReserved(now); err != nil {
+			return 0, 0, err
+		}
+		count := min(n, maxSeq-first+1)
+		state := diff<<stateShift | rollbackSeq<<l.rollbackShift() | (first + count - 1)
+		if ig.state.CompareAndSwap(old, state) {
+			ig.generated.Add(count)
+			return ig.compose(diff, rollbackSeq, first), count, nil
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package idgen
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"math/rand/v2"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// NanoidAlphabet nanoid 默认 URL 安全字符表 (64个字符)
+	NanoidAlphabet = "useandom-26T198340PX75pxJACKVERYMINDBUSHWOLF_GQZbfghjklqvwyzrict"
+	// NanoidSize nanoid 默认长度, 碰撞概率与 UUIDv4 相当
+	NanoidSize = 21
+)
+
+// NanoidGenerator nanoid 生成器
+//
+// 使用 crypto/rand 随机数, 按掩码取位后丢弃超出字符表范围的值 (拒绝采样), 各字符概率均等无偏差,
+// 可用于邀请码/短链接等安全相关场景
+//
+// 规范: https://github.com/ai/nanoid
+type NanoidGenerator struct {
+	alphabet string
+	size     int
+	mask     byte //大于等于字符表长度的最小 2^n-1
+	step     int  //每次读取的随机字节数
+}
+
+// NewNanoid 创建 nanoid 生成器
+//   - {alphabet} 字符表, 2-128 个不重复的 ASCII 字符, 为空时使用 NanoidAlphabet
+//   - {size} 长度, 小于等于 0 时使用 NanoidSize
+//
+// 注: 重复字符会使其出现概率偏高, 多字节字符无法按字节索引, 均会报错
+func NewNanoid(alphabet string, size int) (*NanoidGenerator, error) {
+	if alphabet == "" {
+		alphabet = NanoidAlphabet
+	}
+	if len(alphabet) < 2 || len(alphabet) > 128 {
+		return nil, fmt.Errorf("idgen: 字符表长度应为 [2, 128], 实际 %d", len(alphabet))
+	}
+	var seen [128]bool
+	for i := 0; i < len(alphabet); i++ {
+		c := alphabet[i]
+		if c >= utf8.RuneSelf {
+			return nil, fmt.Errorf("idgen: 字符表仅支持 ASCII 字符, 位置 %d", i)
+		}
+		if seen[c] {
+			return nil, fmt.Errorf("idgen: 字符表存在重复字符 %q", c)
+		}
+		seen[c] = true
+	}
+	if size <= 0 {
+		size = NanoidSize
+	}
+	mask := byte(1<<bits.Len(uint(len(alphabet)-1)) - 1)
+	// 与 nanoid 一致, 1.6 倍冗余以减少重复读取随机数的次数
+	step := int(math.Ceil(1.6 * float64(mask) * float64(size) / float64(len(alphabet))))
+	return &NanoidGenerator{alphabet: alphabet, size: size, mask: mask, step: step}, nil
+}
+
+// Next 生成ID
+func (g *NanoidGenerator) Next() (string, error) {
+	out := make([]byte, 0, g.size)
+	buf := make([]byte, g.step)
+	for {
+		if err := randRead(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if idx := int(b & g.mask); idx < len(g.alphabet) {
+				out = append(out, g.alphabet[idx])
+				if len(out) == g.size {
+					return string(out), nil
+				}
+			}
+		}
+	}
+}
+
+// Nanoid 使用默认字符表生成 nanoid
+//   - {size} 可选, 长度, 默认 21
+func Nanoid(size ...int) (string, error) {
+	n := NanoidSize
+	if len(size) != 0 {
+		n = size[0]
+	}
+	g, err := NewNanoid(NanoidAlphabet, n)
+	if err != nil {
+		return "", err
+	}
+	return g.Next()
+}
+
+// CollisionProbability 随机ID碰撞概率 (生日问题近似)
+//   - {alphabetSize} 字符表长度
+//   - {length} ID 长度
+//   - {count} 生成ID总数
+func CollisionProbability(alphabetSize, length int, count float64) float64 {
+	if count < 2 {
+		return 0
+	}
+	// p ≈ 1 - e^(-n(n-1)/2N), N = alphabetSize^length, 在对数空间计算避免溢出
+	lnPairs := math.Log(count) + math.Log(count-1) - math.Ln2
+	lnSpace := float64(length) * math.Log(float64(alphabetSize))
+	return -math.Expm1(-math.Exp(lnPairs - lnSpace))
+}
+
+// SafeLength 满足碰撞概率不超过 {probability} 的最短ID长度
+//   - {alphabetSize} 字符表长度
+//   - {count} 生成ID总数
+//   - {probability} 可接受的碰撞概率, 如 1e-9
+//
+// 注: 参数无效 (字符表长度小于2, 概率不大于0, 总数非有限值) 时返回 0
+func SafeLength(alphabetSize int, count, probability float64) int {
+	if alphabetSize < 2 || !(probability > 0) || math.IsNaN(count) || math.IsInf(count, 0) {
+		return 0
+	}
+	for length := 1; ; length++ {
+		if CollisionProbability(alphabetSize, length, count) <= probability {
+			return length
+		}
+	}
+}
+
+// ============================================================
+
+// shortIdAlphabet ShortID 字符表 (64个字符, 含特殊字符 "_" "-")
+const shortIdAlphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ_-"
+
+// shortIdMaxCounter 同毫秒计数上限 (2位字符)
+const shortIdMaxCounter = 64*64 - 1
+
+// ShortIDGenerator ShortID 生成器 (参考 teris-io/shortid)
+//
+// 由 [7位毫秒时间戳][1位程序ID][0~n位同毫秒计数] 组成, 以种子打乱的64字符表编码,
+// 长度为 8~10 位 (同毫秒计数超出 2 位时等待下一毫秒), 同一程序ID内唯一; 字符表打乱仅用于混淆, 不具备安全性
+type ShortIDGenerator struct {
+	alphabet string
+	worker   byte
+
+	mu      sync.Mutex
+	lastMs  int64
+	counter uint64
+
+	clock func() time.Time
+}
+
+// NewShortID 创建 ShortID 生成器
+//   - {worker} 程序ID [0, 63]
+//   - {seed} 打乱字符表的种子, 同一业务各实例应相同
+func NewShortID(worker uint8, seed uint64) (*ShortIDGenerator, error) {
+	if worker > 63 {
+		return nil, fmt.Errorf("%w: worker 范围应为 [0, 63]", ErrInvalidNode)
+	}
+	abc := []byte(shortIdAlphabet)
+	r := rand.New(rand.NewPCG(seed, seed^0x9E3779B97F4A7C15))
+	r.Shuffle(len(abc), func(i, j int) { abc[i], abc[j] = abc[j], abc[i] })
+	return &ShortIDGenerator{alphabet: string(abc), worker: worker, clock: time.Now}, nil
+}
+
+// Next 生成ID
+func (g *ShortIDGenerator) Next() (string, error) {
+	g.mu.Lock()
+	ms := g.clock().UnixMilli() - defaultEpoch.UnixMilli()
+	if ms <= g.lastMs && g.counter >= shortIdMaxCounter {
+		// 计数溢出, 等待至下一毫秒
+		for ms <= g.lastMs {
+			time.Sleep(time.Duration(g.lastMs-ms+1) * time.Millisecond / 2)
+			ms = g.clock().UnixMilli() - defaultEpoch.UnixMilli()
+		}
+	}
+	if ms <= g.lastMs {
+		ms = g.lastMs
+		g.counter++
+	} else {
+		g.counter = 0
+	}
+	g.lastMs = ms
+	counter := g.counter
+	g.mu.Unlock()
+
+	if ms < 0 || ms >= 1<<42 {
+		return "", fmt.Errorf("%w: 时间超出 ShortID 范围", ErrTimeOverflow)
+	}
+	out := make([]byte, 0, 10)
+	for i := 6; i >= 0; i-- {
+		out = append(out, g.alphabet[(ms>>(6*i))&63])
+	}
+	out = append(out, g.alphabet[g.worker])
+	for ; counter > 0; counter >>= 6 {
+		out = append(out, g.alphabet[counter&63])
+	}
+	return string(out), nil
+}
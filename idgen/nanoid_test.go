@@ -0,0 +1,94 @@
+package idgen_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/ackcoder/go-mods/idgen"
+)
+
+func TestNanoid(t *testing.T) {
+	id, err := idgen.Nanoid()
+	if err != nil || len(id) != idgen.NanoidSize {
+		t.Fatal(id, err)
+	}
+	t.Log(id)
+
+	// 非 2^n 长度字符表, 检查各字符分布均匀 (无取模偏差)
+	g, err := idgen.NewNanoid("0123456789", 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := make(map[rune]int)
+	const total = 20000
+	for i := 0; i < total/8; i++ {
+		s, err := g.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(s) != 8 || strings.Trim(s, "0123456789") != "" {
+			t.Fatal("格式错误", s)
+		}
+		for _, c := range s {
+			counts[c]++
+		}
+	}
+	for c, n := range counts {
+		if math.Abs(float64(n)-total/10) > total/10*0.1 {
+			t.Errorf("字符 %c 出现 %d 次, 分布不均", c, n)
+		}
+	}
+
+	for _, alphabet := range []string{"a", "abca", "abc中文"} {
+		if _, err = idgen.NewNanoid(alphabet, 8); err == nil {
+			t.Error("字符表过短/重复/含多字节字符理应报错", alphabet)
+		}
+	}
+}
+
+func TestCollisionProbability(t *testing.T) {
+	// 默认 nanoid 生成 10 亿个ID的碰撞概率极低
+	if p := idgen.CollisionProbability(64, 21, 1e9); p > 1e-15 {
+		t.Error("碰撞概率计算错误", p)
+	}
+	// 365 天生日问题, 23 人约 50%
+	if p := idgen.CollisionProbability(365, 1, 23); math.Abs(p-0.5) > 0.02 {
+		t.Error("碰撞概率计算错误", p)
+	}
+	n := idgen.SafeLength(62, 1e6, 1e-6)
+	t.Log("Base62 一百万个ID、碰撞概率一百万分之一所需长度", n)
+	if idgen.CollisionProbability(62, n, 1e6) > 1e-6 || idgen.CollisionProbability(62, n-1, 1e6) <= 1e-6 {
+		t.Error("最短长度计算错误", n)
+	}
+	if idgen.SafeLength(1, 1e6, 1e-6) != 0 || idgen.SafeLength(62, 1e6, 0) != 0 || idgen.SafeLength(62, math.Inf(1), 1e-6) != 0 {
+		t.Error("参数无效理应返回 0")
+	}
+}
+
+func TestShortID(t *testing.T) {
+	g, err := idgen.NewShortID(3, 2024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[string]struct{})
+	for i := 0; i < 10000; i++ {
+		s, err := g.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(s) < 8 || len(s) > 10 {
+			t.Fatal("长度错误", s)
+		}
+		if _, ok := seen[s]; ok {
+			t.Fatal("id 重复", s)
+		}
+		seen[s] = struct{}{}
+		if i < 3 {
+			t.Log(s)
+		}
+	}
+	if _, err = idgen.NewShortID(64, 1); err == nil {
+		t.Error("程序ID 超出范围理应报错")
+	}
+}
@@ -0,0 +1,90 @@
+package idgen
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// feistelRounds Feistel 网络轮数
+const feistelRounds = 8
+
+// Obfuscator 可逆ID混淆器
+//
+// 使用以密钥+盐值派生轮密钥的 Feistel 网络 (轮函数为 HMAC-SHA256) 对 uint64 做一一映射,
+// 再以定长编码输出, 对外隐藏雪花算法ID中的时间与递增规律; 不同盐值的映射互不相关,
+// 同一ID在不同资源下的对外字串不同
+//
+//	注: 仅用于混淆, 不可替代权限校验
+type Obfuscator struct {
+	secret    []byte
+	enc       *Encoding
+	roundKeys [feistelRounds][]byte
+}
+
+// NewObfuscator 创建ID混淆器
+//   - {secret} 密钥, 泄露后映射可被还原, 更换后已发出的字串无法解码
+//   - {salt} 盐值, 建议按资源区分, 如 "user", "order"
+//   - {enc} 可选, 输出编码, 默认 Base62 定长 (11位)
+func NewObfuscator(secret, salt string, enc ...*Encoding) *Obfuscator {
+	o := &Obfuscator{
+		secret: []byte(secret),
+		enc:    Base62.WithFixedWidth(),
+	}
+	if len(enc) != 0 {
+		o.enc = enc[0]
+	}
+	for i := range o.roundKeys {
+		h := hmac.New(sha256.New, o.secret)
+		h.Write([]byte{byte(i)})
+		h.Write([]byte(salt))
+		o.roundKeys[i] = h.Sum(nil)
+	}
+	return o
+}
+
+// WithSalt 以相同密钥与编码创建另一盐值的混淆器
+func (o *Obfuscator) WithSalt(salt string) *Obfuscator {
+	return NewObfuscator(string(o.secret), salt, o.enc)
+}
+
+// Encode 混淆ID
+func (o *Obfuscator) Encode(id uint64) string {
+	return o.enc.Encode(o.Permute(id))
+}
+
+// Decode 还原ID
+func (o *Obfuscator) Decode(s string) (uint64, error) {
+	v, err := o.enc.Decode(s)
+	if err != nil {
+		return 0, err
+	}
+	return o.Unpermute(v), nil
+}
+
+// Permute 数值形式的混淆 (不编码)
+func (o *Obfuscator) Permute(id uint64) uint64 {
+	l, r := uint32(id>>32), uint32(id)
+	for i := 0; i < feistelRounds; i++ {
+		l, r = r, l^o.round(i, r)
+	}
+	return uint64(l)<<32 | uint64(r)
+}
+
+// Unpermute Permute 的逆操作
+func (o *Obfuscator) Unpermute(v uint64) uint64 {
+	l, r := uint32(v>>32), uint32(v)
+	for i := feistelRounds - 1; i >= 0; i-- {
+		l, r = r^o.round(i, l), l
+	}
+	return uint64(l)<<32 | uint64(r)
+}
+
+// round 轮函数
+func (o *Obfuscator) round(i int, half uint32) uint32 {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], half)
+	h := hmac.New(sha256.New, o.roundKeys[i])
+	h.Write(buf[:])
+	return binary.BigEndian.Uint32(h.Sum(nil))
+}
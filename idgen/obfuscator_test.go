@@ -0,0 +1,42 @@
+package idgen_test
+
+import (
+	"testing"
+
+	"github.com/ackcoder/go-mods/idgen"
+)
+
+func TestObfuscator(t *testing.T) {
+	users := idgen.NewObfuscator("my-secret", "user")
+	orders := users.WithSalt("order")
+	id := idgen.New(1, 1)
+
+	seen := make(map[string]struct{})
+	for i := 0; i < 1000; i++ {
+		v := id.GenNum()
+		s := users.Encode(v)
+		if len(s) != 11 {
+			t.Fatal("长度应为 11", s)
+		}
+		if _, ok := seen[s]; ok {
+			t.Fatal("字串重复", s)
+		}
+		seen[s] = struct{}{}
+		if got, err := users.Decode(s); err != nil || got != v {
+			t.Fatal("还原错误", v, s, got, err)
+		}
+		if orders.Encode(v) == s {
+			t.Fatal("不同盐值映射应不同", s)
+		}
+		if i < 3 {
+			t.Log(v, s, orders.Encode(v))
+		}
+	}
+
+	// 不同密钥无法还原
+	other := idgen.NewObfuscator("other-secret", "user")
+	v := id.GenNum()
+	if got, _ := other.Decode(users.Encode(v)); got == v {
+		t.Error("不同密钥不应还原成功")
+	}
+}
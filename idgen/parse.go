@@ -0,0 +1,69 @@
+package idgen
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// IdInfo 雪花算法ID 解析结果
+type IdInfo struct {
+	Id           uint64    `json:"id"`
+	Time         time.Time `json:"time"`         //生成时间 (精度为 layout.TimeUnit)
+	DataCenterId uint64    `json:"dataCenterId"` //集群ID
+	WorkerId     uint64    `json:"workerId"`     //程序ID
+	Rollback     uint64    `json:"rollback"`     //回拨序号 (布局 RollbackBits 为 0 时恒为 0)
+	Sequence     uint64    `json:"sequence"`     //序列号
+}
+
+// Parse 解析雪花算法ID
+//
+//	注: 需使用与生成时相同的位布局与起始点, 否则解析结果无意义
+func (ig *IdGenerator) Parse(id uint64) IdInfo {
+	l := ig.layout
+	return IdInfo{
+		Id:           id,
+		Time:         l.fromTick(ig.epoch + int64(id>>l.timeShift())),
+		DataCenterId: (id >> l.dataCenterShift()) & l.MaxDataCenterId(),
+		WorkerId:     (id >> l.workerShift()) & l.MaxWorkerId(),
+		Rollback:     (id >> l.rollbackShift()) & l.MaxRollback(),
+		Sequence:     id & l.MaxSequence(),
+	}
+}
+
+// ParseString 解析雪花算法ID (10进制位字串, 即 Gen 的返回值)
+func (ig *IdGenerator) ParseString(id string) (IdInfo, error) {
+	v, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return IdInfo{}, fmt.Errorf("idgen: 解析ID %q 失败: %w", id, err)
+	}
+	return ig.Parse(v), nil
+}
+
+// MinIdAt 指定时间点可能生成的最小ID
+//
+// 可配合 MaxIdAt 按ID做时间范围查询, 如: WHERE id BETWEEN MinIdAt(start) AND MaxIdAt(end)
+//
+//	注: 早于起始点的时间按起始点算, 超出时间戳期限的按期限算
+func (ig *IdGenerator) MinIdAt(t time.Time) uint64 {
+	return ig.tickDiffAt(t) << ig.layout.timeShift()
+}
+
+// MaxIdAt 指定时间点可能生成的最大ID
+//
+//	注: 早于起始点的时间按起始点算, 超出时间戳期限的按期限算
+func (ig *IdGenerator) MaxIdAt(t time.Time) uint64 {
+	return ig.MinIdAt(t) | bitsMax(ig.layout.timeShift())
+}
+
+// tickDiffAt 指定时间点距起始点的时间戳差值
+func (ig *IdGenerator) tickDiffAt(t time.Time) uint64 {
+	diff := ig.layout.toTick(t) - ig.epoch
+	if diff < 0 {
+		return 0
+	}
+	if uint64(diff) > bitsMax(ig.layout.TimeBits) {
+		return bitsMax(ig.layout.TimeBits)
+	}
+	return uint64(diff)
+}
@@ -0,0 +1,242 @@
+package idgen
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrUnknownGenerator 未配置的生成器名称
+var ErrUnknownGenerator = errors.New("idgen: unknown generator")
+
+// Stats 生成统计
+type Stats struct {
+	Generated uint64  `json:"generated"` //已生成ID数
+	Overflows uint64  `json:"overflows"` //序列号溢出次数 (需等待或借用下一时间单位)
+	Rollbacks uint64  `json:"rollbacks"` //时钟回拨次数
+	Rate      float64 `json:"rate"`      //每秒生成ID数
+}
+
+// Stats 生成统计, Rate 为创建以来的平均值
+func (ig *IdGenerator) Stats() Stats {
+	st := Stats{
+		Generated: ig.generated.Load(),
+		Overflows: ig.overflows.Load(),
+		Rollbacks: ig.rollbacks.Load(),
+	}
+	if elapsed := time.Since(ig.created).Seconds(); elapsed > 0 {
+		st.Rate = float64(st.Generated) / elapsed
+	}
+	return st
+}
+
+// LayoutByName 按名称获取预设位布局
+//   - {name} "twitter"(或空), "sonyflake", "baidu", 不区分大小写
+func LayoutByName(name string) (Layout, error) {
+	switch strings.ToLower(name) {
+	case "", "twitter":
+		return LayoutTwitter, nil
+	case "sonyflake":
+		return LayoutSonyflake, nil
+	case "baidu":
+		return LayoutBaidu, nil
+	}
+	return Layout{}, fmt.Errorf("%w: 未知预设 %q", ErrInvalidLayout, name)
+}
+
+// ============================================================
+
+// GeneratorConfig 生成器配置
+//
+// 同时带有 json 与 yaml 标签, 可用任意 YAML 库解析为 map[string]GeneratorConfig 后传给 NewRegistry
+type GeneratorConfig struct {
+	Epoch        time.Time `json:"epoch" yaml:"epoch"`               //起始点 (RFC3339), 为空时使用默认值
+	DataCenterId uint64    `json:"dataCenterId" yaml:"dataCenterId"` //集群ID
+	WorkerId     uint64    `json:"workerId" yaml:"workerId"`         //程序ID
+	Layout       string    `json:"layout" yaml:"layout"`             //预设位布局名称, 见 LayoutByName
+	Rollback     string    `json:"rollback" yaml:"rollback"`         //时钟回拨策略 "wait"(默认), "fail", "borrow", "bits"
+	RollbackMax  string    `json:"rollbackMax" yaml:"rollbackMax"`   //wait/borrow 策略的时长, 如 "900ms"
+	LockFree     bool      `json:"lockFree" yaml:"lockFree"`         //是否使用无锁模式
+}
+
+// Build 按配置创建ID生成器
+func (c GeneratorConfig) Build() (*IdGenerator, error) {
+	layout, err := LayoutByName(c.Layout)
+	if err != nil {
+		return nil, err
+	}
+	var start []time.Time
+	if !c.Epoch.IsZero() {
+		start = append(start, c.Epoch)
+	}
+	ig, err := NewWithLayoutE(layout, c.DataCenterId, c.WorkerId, start...)
+	if err != nil {
+		return nil, err
+	}
+
+	var rollbackMax time.Duration
+	if c.RollbackMax != "" {
+		if rollbackMax, err = time.ParseDuration(c.RollbackMax); err != nil {
+			return nil, fmt.Errorf("idgen: rollbackMax 格式错误: %w", err)
+		}
+	}
+	switch strings.ToLower(c.Rollback) {
+	case "", "wait":
+		if rollbackMax > 0 {
+			ig.SetRollbackPolicy(RollbackWait(rollbackMax))
+		}
+	case "fail":
+		ig.SetRollbackPolicy(RollbackFail())
+	case "borrow":
+		ig.SetRollbackPolicy(RollbackBorrow(rollbackMax))
+	case "bits":
+		ig.SetRollbackPolicy(RollbackBits())
+	default:
+		return nil, fmt.Errorf("idgen: 未知时钟回拨策略 %q", c.Rollback)
+	}
+	return ig.SetLockFree(c.LockFree), nil
+}
+
+// ============================================================
+
+// Registry 多业务ID生成器注册表
+//
+// 按名称 (业务标识) 懒加载并缓存各自的 IdGenerator, 并发安全
+type Registry struct {
+	mu      sync.RWMutex
+	configs map[string]GeneratorConfig
+	gens    map[string]*IdGenerator
+
+	statsMu   sync.Mutex
+	lastStats map[string]Stats //上次统计快照, 用于计算区间速率
+	lastAt    time.Time
+}
+
+// NewRegistry 创建注册表
+//   - {configs} 各业务生成器配置, 键为名称
+func NewRegistry(configs map[string]GeneratorConfig) *Registry {
+	cp := make(map[string]GeneratorConfig, len(configs))
+	for k, v := range configs {
+		cp[k] = v
+	}
+	return &Registry{
+		configs:   cp,
+		gens:      make(map[string]*IdGenerator),
+		lastStats: make(map[string]Stats),
+		lastAt:    time.Now(),
+	}
+}
+
+// LoadRegistry 从 JSON 配置创建注册表
+//
+// 配置格式: {"order": {"workerId": 1, "layout": "twitter"}, "user": {...}}
+func LoadRegistry(r io.Reader) (*Registry, error) {
+	var configs map[string]GeneratorConfig
+	if err := json.NewDecoder(r).Decode(&configs); err != nil {
+		return nil, fmt.Errorf("idgen: 解析注册表配置失败: %w", err)
+	}
+	return NewRegistry(configs), nil
+}
+
+// Get 获取指定名称的ID生成器, 首次获取时按配置创建
+func (r *Registry) Get(name string) (*IdGenerator, error) {
+	r.mu.RLock()
+	ig, ok := r.gens[name]
+	r.mu.RUnlock()
+	if ok {
+		return ig, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if ig, ok = r.gens[name]; ok {
+		return ig, nil
+	}
+	conf, ok := r.configs[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownGenerator, name)
+	}
+	ig, err := conf.Build()
+	if err != nil {
+		return nil, fmt.Errorf("idgen: 创建生成器 %s 失败: %w", name, err)
+	}
+	r.gens[name] = ig
+	return ig, nil
+}
+
+// MustGet 同 Get, 出错时 panic
+func (r *Registry) MustGet(name string) *IdGenerator {
+	ig, err := r.Get(name)
+	if err != nil {
+		panic(err)
+	}
+	return ig
+}
+
+// Register 注册已创建的ID生成器 (如自定义位布局), 名称已存在时返回错误
+func (r *Registry) Register(name string, ig *IdGenerator) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.gens[name]; ok {
+		return fmt.Errorf("idgen: 生成器 %s 已存在", name)
+	}
+	if _, ok := r.configs[name]; ok {
+		return fmt.Errorf("idgen: 生成器 %s 已存在", name)
+	}
+	r.gens[name] = ig
+	return nil
+}
+
+// Names 已配置与已注册的生成器名称
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.configs)+len(r.gens))
+	for name := range r.configs {
+		names = append(names, name)
+	}
+	for name := range r.gens {
+		if _, ok := r.configs[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Stats 各生成器统计 (仅已创建的), 以及汇总统计
+//
+// Rate 为距上次调用 Stats 期间的每秒生成ID数
+func (r *Registry) Stats() (each map[string]Stats, total Stats) {
+	r.mu.RLock()
+	gens := make(map[string]*IdGenerator, len(r.gens))
+	for name, ig := range r.gens {
+		gens[name] = ig
+	}
+	r.mu.RUnlock()
+
+	r.statsMu.Lock()
+	defer r.statsMu.Unlock()
+	now := time.Now()
+	elapsed := now.Sub(r.lastAt).Seconds()
+	each = make(map[string]Stats, len(gens))
+	for name, ig := range gens {
+		st := ig.Stats()
+		st.Rate = 0
+		if elapsed > 0 {
+			st.Rate = float64(st.Generated-r.lastStats[name].Generated) / elapsed
+		}
+		each[name] = st
+		r.lastStats[name] = st
+
+		total.Generated += st.Generated
+		total.Overflows += st.Overflows
+		total.Rollbacks += st.Rollbacks
+		total.Rate += st.Rate
+	}
+	r.lastAt = now
+	return
+}
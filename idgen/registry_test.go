@@ -0,0 +1,62 @@
+package idgen_test
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ackcoder/go-mods/idgen"
+)
+
+func TestRegistry(t *testing.T) {
+	reg, err := idgen.LoadRegistry(strings.NewReader(`{
+		"order": {"workerId": 1, "epoch": "2023-01-01T00:00:00Z"},
+		"user":  {"workerId": 2, "layout": "sonyflake", "rollback": "borrow", "rollbackMax": "50ms", "lockFree": true},
+		"bad":   {"workerId": 99}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	gens := make([]*idgen.IdGenerator, 8)
+	for i := range gens {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			gens[i] = reg.MustGet("order")
+			for j := 0; j < 1000; j++ {
+				gens[i].GenNum()
+			}
+		}()
+	}
+	wg.Wait()
+	for _, ig := range gens[1:] {
+		if ig != gens[0] {
+			t.Fatal("同名称应返回同一实例")
+		}
+	}
+	if gens[0].Epoch().Year() != 2023 {
+		t.Error("起始点配置未生效", gens[0].Epoch())
+	}
+
+	user := reg.MustGet("user")
+	if user.Layout() != idgen.LayoutSonyflake {
+		t.Error("位布局配置未生效")
+	}
+	user.GenNum()
+
+	if _, err = reg.Get("bad"); !errors.Is(err, idgen.ErrInvalidNode) {
+		t.Error("配置错误理应报错", err)
+	}
+	if _, err = reg.Get("none"); !errors.Is(err, idgen.ErrUnknownGenerator) {
+		t.Error("未配置名称理应报错", err)
+	}
+
+	each, total := reg.Stats()
+	t.Logf("%+v %+v", each, total)
+	if each["order"].Generated != 8000 || total.Generated != 8001 {
+		t.Error("统计错误", each, total)
+	}
+}
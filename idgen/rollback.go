@@ -0,0 +1,101 @@
+package idgen
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrInvalidNode 集群ID/程序ID 超出位布局范围
+	ErrInvalidNode = errors.New("idgen: node id out of range")
+	// ErrClockBackwards 时钟回拨且回拨策略无法处理
+	ErrClockBackwards = errors.New("idgen: clock moved backwards")
+	// ErrTimeOverflow 超出位布局时间戳期限
+	ErrTimeOverflow = errors.New("idgen: timestamp overflow")
+)
+
+type rollbackMode int
+
+const (
+	rollbackFail rollbackMode = iota
+	rollbackWait
+	rollbackBorrow
+	rollbackBits
+)
+
+// RollbackPolicy 时钟回拨处理策略
+//
+// 可用策略: RollbackFail, RollbackWait, RollbackBorrow, RollbackBits
+type RollbackPolicy struct {
+	mode rollbackMode
+	max  time.Duration //最长等待时间/最多借用时间
+}
+
+// RollbackFail 时钟回拨时直接返回 ErrClockBackwards
+func RollbackFail() RollbackPolicy {
+	return RollbackPolicy{mode: rollbackFail}
+}
+
+// RollbackWait 时钟回拨时等待时钟追上最后时间戳
+//   - {max} 最长等待时间, 回拨幅度超出时不等待直接返回 ErrClockBackwards
+//
+// 注: 等待期间不持有锁
+func RollbackWait(max time.Duration) RollbackPolicy {
+	return RollbackPolicy{mode: rollbackWait, max: max}
+}
+
+// RollbackBorrow 时钟回拨时借用未来时间 (逻辑时钟)
+//   - {max} 最多领先系统时钟的时间, 超出时返回 ErrClockBackwards
+//
+// 回拨期间沿用最后时间戳继续递增序列号, 序列号溢出时不再等待而是直接借用下一时间单位,
+// 待系统时钟追上后恢复正常; 代价是ID中的时间会略早于实际生成时间
+func RollbackBorrow(max time.Duration) RollbackPolicy {
+	return RollbackPolicy{mode: rollbackBorrow, max: max}
+}
+
+// RollbackBits 时钟回拨时递增ID中的回拨序号后照常生成
+//
+// 需位布局 RollbackBits 不为 0 (为 0 时等同 RollbackFail),
+// 回拨序号不同的ID不会重复, 回拨次数超过 2^RollbackBits 后循环复用
+func RollbackBits() RollbackPolicy {
+	return RollbackPolicy{mode: rollbackBits}
+}
+
+// handleRollback 处理时钟回拨 (调用时需持有锁)
+//
+// 返回继续生成ID所用时间戳
+func (ig *IdGenerator) handleRollback(now int64, policy *RollbackPolicy) (int64, error) {
+	switch policy.mode {
+	case rollbackWait:
+		deadline := ig.clock().Add(policy.max)
+		for ig.timestamp > now {
+			wait := ig.layout.fromTick(ig.timestamp).Sub(ig.layout.fromTick(now))
+			if ig.clock().Add(wait).After(deadline) {
+				break
+			}
+			ig.mu.Unlock()
+			time.Sleep(wait)
+			ig.mu.Lock()
+			now = ig.layout.toTick(ig.clock())
+		}
+		if ig.timestamp <= now {
+			return now, nil
+		}
+	case rollbackBorrow:
+		if ig.borrowable(ig.timestamp, policy) {
+			return ig.timestamp, nil
+		}
+	case rollbackBits:
+		if ig.layout.RollbackBits != 0 {
+			ig.rollbackSeq = (ig.rollbackSeq + 1) & ig.layout.MaxRollback()
+			return now, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: 最后时间戳 %d, 比较时间戳 %d", ErrClockBackwards, ig.timestamp, now)
+}
+
+// borrowable 时间戳是否在可借用范围内
+func (ig *IdGenerator) borrowable(tick int64, policy *RollbackPolicy) bool {
+	return ig.layout.fromTick(tick).Sub(ig.clock()) <= policy.max
+}
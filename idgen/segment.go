@@ -0,0 +1,245 @@
+package idgen
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrTagNotFound 业务标识不存在
+	ErrTagNotFound = errors.New("idgen: segment tag not found")
+	// ErrInvalidSegment 号段存储返回的号段无效 (大小为 0 或起止颠倒)
+	ErrInvalidSegment = errors.New("idgen: invalid segment")
+)
+
+// Segment 号段 [Start, End)
+type Segment struct {
+	Start uint64
+	End   uint64
+}
+
+// Size 号段大小
+func (s Segment) Size() uint64 {
+	return s.End - s.Start
+}
+
+// validate 校验号段, 无效号段会使取号无限请求存储
+func (s Segment) validate(tag string) error {
+	if s.End <= s.Start {
+		return fmt.Errorf("%w: %s [%d, %d)", ErrInvalidSegment, tag, s.Start, s.End)
+	}
+	return nil
+}
+
+// SegmentStore 号段存储
+type SegmentStore interface {
+	// NextSegment 为业务标识分配下一号段, 各次分配的号段不可重叠且大小须大于 0
+	NextSegment(ctx context.Context, tag string) (Segment, error)
+}
+
+// ============================================================
+
+// SegmentGenerator 号段模式ID生成器 (美团 Leaf-segment 方案)
+//
+// 每个业务标识维护双号段缓冲, 当前号段剩余不足 10% 时后台预取下一号段,
+// 当前号段用尽后直接切换, 避免每次取号段时请求存储的延迟
+//
+//	注: 生成的ID趋势递增, 但服务重启或多实例时不连续
+type SegmentGenerator struct {
+	store   SegmentStore
+	timeout time.Duration //后台预取号段超时
+
+	mu      sync.Mutex
+	buffers map[string]*segmentBuffer
+}
+
+// NewSegmentGenerator 创建号段模式ID生成器
+//   - {store} 号段存储, 可用 NewMemorySegmentStore, NewSQLSegmentStore
+func NewSegmentGenerator(store SegmentStore) *SegmentGenerator {
+	return &SegmentGenerator{
+		store:   store,
+		timeout: 10 * time.Second,
+		buffers: make(map[string]*segmentBuffer),
+	}
+}
+
+// Next 生成指定业务标识的下一个ID
+//   - {tag} 业务标识, 如 "order"
+func (g *SegmentGenerator) Next(ctx context.Context, tag string) (uint64, error) {
+	g.mu.Lock()
+	buf, ok := g.buffers[tag]
+	if !ok {
+		buf = &segmentBuffer{tag: tag, gen: g}
+		buf.cond = sync.NewCond(&buf.mu)
+		g.buffers[tag] = buf
+	}
+	g.mu.Unlock()
+	return buf.take(ctx)
+}
+
+// segmentBuffer 单个业务标识的双号段缓冲
+type segmentBuffer struct {
+	tag string
+	gen *SegmentGenerator
+
+	mu       sync.Mutex
+	cond     *sync.Cond //预取完成通知
+	current  Segment
+	cursor   uint64   //当前号段下一个可用ID
+	prepared *Segment //已预取的下一号段
+	loading  bool     //是否正在预取 (预取失败时于号段用尽后同步重试)
+}
+
+func (b *segmentBuffer) take(ctx context.Context) (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for b.cursor >= b.current.End {
+		switch {
+		case b.prepared != nil:
+			b.current, b.cursor, b.prepared = *b.prepared, b.prepared.Start, nil
+		case b.loading:
+			b.cond.Wait()
+		default:
+			// 首次使用或预取失败, 同步获取号段
+			seg, err := b.gen.store.NextSegment(ctx, b.tag)
+			if err == nil {
+				err = seg.validate(b.tag)
+			}
+			if err != nil {
+				return 0, err
+			}
+			b.current, b.cursor = seg, seg.Start
+		}
+	}
+
+	id := b.cursor
+	b.cursor++
+
+	// 剩余不足 10% 时后台预取下一号段
+	if b.prepared == nil && !b.loading && (b.current.End-b.cursor)*10 <= b.current.Size() {
+		b.loading = true
+		go b.prefetch()
+	}
+	return id, nil
+}
+
+func (b *segmentBuffer) prefetch() {
+	ctx, cancel := context.WithTimeout(context.Background(), b.gen.timeout)
+	defer cancel()
+	seg, err := b.gen.store.NextSegment(ctx, b.tag)
+	if err == nil {
+		err = seg.validate(b.tag)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.loading = false
+	if err == nil {
+		b.prepared = &seg
+	}
+	b.cond.Broadcast()
+}
+
+// ============================================================
+
+// MemorySegmentStore 内存号段存储 (仅限单进程内使用, 多用于测试)
+//
+// 与 Leaf 一致, ID 从 1 开始
+type MemorySegmentStore struct {
+	mu    sync.Mutex
+	step  uint64
+	maxId map[string]uint64
+}
+
+// NewMemorySegmentStore 创建内存号段存储
+//   - {step} 号段大小, 为 0 时默认 1000
+func NewMemorySegmentStore(step uint64) *MemorySegmentStore {
+	if step == 0 {
+		step = 1000
+	}
+	return &MemorySegmentStore{step: step, maxId: make(map[string]uint64)}
+}
+
+func (s *MemorySegmentStore) NextSegment(_ context.Context, tag string) (Segment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	start, ok := s.maxId[tag]
+	if !ok {
+		start = 1
+	}
+	s.maxId[tag] = start + s.step
+	return Segment{Start: start, End: start + s.step}, nil
+}
+
+// SQLSegmentStore 数据库号段存储
+//
+// 表结构参考 (MySQL):
+//
+//	CREATE TABLE leaf_alloc (
+//	  biz_tag     VARCHAR(128) NOT NULL PRIMARY KEY,
+//	  max_id      BIGINT NOT NULL DEFAULT 1,
+//	  step        INT NOT NULL,
+//	  update_time TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+//	);
+//	INSERT INTO leaf_alloc (biz_tag, max_id, step) VALUES ('order', 1, 1000);
+//
+// 每次分配在事务中执行 max_id = max_id + step, 号段为 [max_id - step, max_id)
+type SQLSegmentStore struct {
+	db     *sql.DB
+	update string
+	query  string
+}
+
+// NewSQLSegmentStore 创建数据库号段存储
+//   - {db} 数据库连接
+//   - {table} 表名, 如 "leaf_alloc"
+//   - {dollarPlaceholder} 可选, 是否使用 $1 占位符 (PostgreSQL), 默认使用 ? 占位符
+func NewSQLSegmentStore(db *sql.DB, table string, dollarPlaceholder ...bool) *SQLSegmentStore {
+	ph := "?"
+	if len(dollarPlaceholder) != 0 && dollarPlaceholder[0] {
+		ph = "$1"
+	}
+	return &SQLSegmentStore{
+		db:     db,
+		update: fmt.Sprintf("UPDATE %s SET max_id = max_id + step WHERE biz_tag = %s", table, ph),
+		query:  fmt.Sprintf("SELECT max_id, step FROM %s WHERE biz_tag = %s", table, ph),
+	}
+}
+
+func (s *SQLSegmentStore) NextSegment(ctx context.Context, tag string) (seg Segment, err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	res, err := tx.ExecContext(ctx, s.update, tag)
+	if err != nil {
+		return
+	}
+	if n, rErr := res.RowsAffected(); rErr == nil && n == 0 {
+		err = fmt.Errorf("%w: %s", ErrTagNotFound, tag)
+		return
+	}
+	var maxId, step uint64
+	if err = tx.QueryRowContext(ctx, s.query, tag).Scan(&maxId, &step); err != nil {
+		return
+	}
+	if step == 0 || step > maxId {
+		err = fmt.Errorf("%w: %s max_id=%d step=%d", ErrInvalidSegment, tag, maxId, step)
+		return
+	}
+	if err = tx.Commit(); err != nil {
+		return
+	}
+	return Segment{Start: maxId - step, End: maxId}, nil
+}
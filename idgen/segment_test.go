@@ -0,0 +1,211 @@
+package idgen_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ackcoder/go-mods/idgen"
+)
+
+// slowSegmentStore 模拟存储延迟并统计请求次数
+type slowSegmentStore struct {
+	*idgen.MemorySegmentStore
+	calls atomic.Int32
+}
+
+func (s *slowSegmentStore) NextSegment(ctx context.Context, tag string) (idgen.Segment, error) {
+	s.calls.Add(1)
+	time.Sleep(5 * time.Millisecond)
+	return s.MemorySegmentStore.NextSegment(ctx, tag)
+}
+
+func TestSegmentGenerator(t *testing.T) {
+	ctx := context.Background()
+	store := &slowSegmentStore{MemorySegmentStore: idgen.NewMemorySegmentStore(100)}
+	gen := idgen.NewSegmentGenerator(store)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	checkMap := make(map[uint64]struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 500; j++ {
+				v, err := gen.Next(ctx, "order")
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				if _, ok := checkMap[v]; ok {
+					t.Error("id 重复: ", v)
+				}
+				checkMap[v] = struct{}{}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(checkMap) != 2000 {
+		t.Error("数量错误", len(checkMap))
+	}
+	t.Log("号段请求次数", store.calls.Load())
+
+	// 不同业务标识互不影响
+	if v, err := gen.Next(ctx, "user"); err != nil || v != 1 {
+		t.Error("新业务标识应从 1 开始", v, err)
+	}
+}
+
+// zeroSegmentStore 返回大小为 0 的号段
+type zeroSegmentStore struct{}
+
+func (zeroSegmentStore) NextSegment(context.Context, string) (idgen.Segment, error) {
+	return idgen.Segment{Start: 5, End: 5}, nil
+}
+
+func TestSegmentInvalid(t *testing.T) {
+	done := make(chan error, 1)
+	go func() {
+		_, err := idgen.NewSegmentGenerator(zeroSegmentStore{}).Next(context.Background(), "order")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, idgen.ErrInvalidSegment) {
+			t.Error("无效号段理应报错", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("无效号段不应无限重试")
+	}
+}
+
+func TestSQLSegmentStore(t *testing.T) {
+	ctx := context.Background()
+	db := openLeafDB(t, map[string][2]uint64{"order": {1, 100}, "zero": {1, 0}})
+	gen := idgen.NewSegmentGenerator(idgen.NewSQLSegmentStore(db, "leaf_alloc"))
+
+	for i := uint64(1); i <= 250; i++ {
+		v, err := gen.Next(ctx, "order")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != i {
+			t.Fatal("id 应从 1 连续递增", v, i)
+		}
+	}
+	if _, err := gen.Next(ctx, "none"); !errors.Is(err, idgen.ErrTagNotFound) {
+		t.Error("业务标识不存在理应报错", err)
+	}
+	if _, err := gen.Next(ctx, "zero"); !errors.Is(err, idgen.ErrInvalidSegment) {
+		t.Error("步长为 0 理应报错", err)
+	}
+}
+
+// ============================================================
+
+// leafDriver 模拟 leaf_alloc 表的 database/sql 驱动, 仅支持 SQLSegmentStore 使用的两条语句
+type leafDriver struct {
+	mu   sync.Mutex
+	rows map[string][2]uint64 //biz_tag -> [max_id, step]
+}
+
+var leafDBs sync.Map
+
+func openLeafDB(t *testing.T, rows map[string][2]uint64) *sql.DB {
+	name := "leaf-" + t.Name()
+	leafDBs.Store(name, &leafDriver{rows: rows})
+	db, err := sql.Open("leaf", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func init() {
+	sql.Register("leaf", leafOpener{})
+}
+
+type leafOpener struct{}
+
+func (leafOpener) Open(name string) (driver.Conn, error) {
+	d, ok := leafDBs.Load(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown db %s", name)
+	}
+	return &leafConn{d: d.(*leafDriver)}, nil
+}
+
+type leafConn struct{ d *leafDriver }
+
+func (c *leafConn) Prepare(query string) (driver.Stmt, error) {
+	return &leafStmt{d: c.d, query: query}, nil
+}
+func (c *leafConn) Close() error { return nil }
+
+// Begin 以表锁模拟行锁, 事务结束时释放
+func (c *leafConn) Begin() (driver.Tx, error) {
+	c.d.mu.Lock()
+	return leafTx{c.d}, nil
+}
+
+type leafTx struct{ d *leafDriver }
+
+func (tx leafTx) Commit() error   { tx.d.mu.Unlock(); return nil }
+func (tx leafTx) Rollback() error { tx.d.mu.Unlock(); return nil }
+
+type leafStmt struct {
+	d     *leafDriver
+	query string
+}
+
+func (s *leafStmt) Close() error  { return nil }
+func (s *leafStmt) NumInput() int { return 1 }
+
+func (s *leafStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if !strings.HasPrefix(s.query, "UPDATE leaf_alloc SET max_id = max_id + step") {
+		return nil, fmt.Errorf("unexpected query %s", s.query)
+	}
+	tag := args[0].(string)
+	row, ok := s.d.rows[tag]
+	if !ok {
+		return driver.RowsAffected(0), nil
+	}
+	s.d.rows[tag] = [2]uint64{row[0] + row[1], row[1]}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *leafStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if !strings.HasPrefix(s.query, "SELECT max_id, step FROM leaf_alloc") {
+		return nil, fmt.Errorf("unexpected query %s", s.query)
+	}
+	row := s.d.rows[args[0].(string)]
+	return &leafRows{row: []driver.Value{int64(row[0]), int64(row[1])}}, nil
+}
+
+type leafRows struct {
+	row  []driver.Value
+	done bool
+}
+
+func (r *leafRows) Columns() []string { return []string{"max_id", "step"} }
+func (r *leafRows) Close() error      { return nil }
+func (r *leafRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	copy(dest, r.row)
+	return nil
+}
@@ -1,9 +1,11 @@
 package idgen
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -24,59 +26,140 @@ const (
 	// sequence occupancy bits 序列占用位
 	sequenceBits = 12
 
-	// timestamp 最大值 (2^41-1 = 2199023255551)
-	timestampMaxValue = -1 ^ (-1 << timestampBits)
-	// dataCenterId 最大值 (2^5-1 = 31)
-	dataCenterIdMaxValue = -1 ^ (-1 << dataCenterIdBits)
-	// workId 最大值 (2^5-1 = 31)
-	workerIdMaxValue = -1 ^ (-1 << workerIdBits)
-	// sequence 最大值 (2^12-1 = 4095)
-	sequenceMaxValue = -1 ^ (-1 << sequenceBits)
-
-	// workId 偏移位数 (sequenceBits)
-	workIdShift = 12
-	// dataCenterId 偏移位数 (sequenceBits + workerIdBits)
-	dataCenterIdShift = 17
-	// timestamp 偏移位数 (sequenceBits + workerIdBits + dataCenterIdBits)
-	timestampShift = 22
-
 	defaultInitValue = 0
 )
 
+// defaultEpoch 默认起始点 UTC: 2024-01-01 00:00:00
+var defaultEpoch = time.Date(2024, time.January, 01, 00, 00, 00, 00, time.UTC)
+
 type IdGenerator struct {
-	epoch        int64  //起始点 毫秒时间戳, 默认 UTC: 2024-01-01 00:00:00
-	timestamp    int64  //记录点 毫秒时间戳 (取 2^41 约 69 年)
-	dataCenterId uint64 //机器码 集群ID (取 2^5)
-	workerId     uint64 //机器码 程序ID (取 2^5, 机器共 2^10=1024 台)
-	sequence     uint64 //序列号 (取 2^12, 即 4096 个)
+	epoch        int64  //起始点 时间戳, 默认 UTC: 2024-01-01 00:00:00
+	timestamp    int64  //记录点 时间戳 (默认布局取 2^41 毫秒约 69 年)
+	dataCenterId uint64 //机器码 集群ID (默认布局取 2^5)
+	workerId     uint64 //机器码 程序ID (默认布局取 2^5, 机器共 2^10=1024 台)
+	sequence     uint64 //序列号 (默认布局取 2^12, 即 4096 个)
+	rollbackSeq  uint64 //回拨序号 (仅 RollbackBits 策略使用)
+
+	layout   Layout                         //位布局, 时间戳单位均为 layout.TimeUnit
+	rollback atomic.Pointer[RollbackPolicy] //时钟回拨处理策略 (无锁模式下不持有锁读取, 故原子存取)
+	clock    func() time.Time               //时间源
+
+	lockFree atomic.Bool   //是否使用无锁模式
+	state    atomic.Uint64 //无锁模式状态字 [时间戳差值][回拨序号][序列号]
+
+	stateStore    StateStore   //状态存储, 未设置时为 nil
+	stateWindow   int64        //每次预留的时间窗口 (单位 layout.TimeUnit)
+	reservedUntil atomic.Int64 //已持久化的高水位时间戳
+	saving        atomic.Bool  //是否正在后台续期
+	persistMu     sync.Mutex
+
+	created   time.Time     //创建时间
+	generated atomic.Uint64 //已生成ID数
+	overflows atomic.Uint64 //序列号溢出次数
+	rollbacks atomic.Uint64 //时钟回拨次数
+
+	provider WorkerIDProvider //程序ID提供者, 手动指定程序ID时为 nil
+	lost     <-chan struct{}  //程序ID 租约丢失通知
+	closed   atomic.Bool      //程序ID 是否已释放
 
 	mu *sync.Mutex
 }
 
-// 创建ID生成器实例
+// 创建ID生成器实例 (默认 LayoutTwitter 布局)
 //   - {dataCenterId} 集群ID [0, 31]
 //   - {workerId} 程序ID [0, 31]
 //   - {start} 可选, 设置起始点, 未来时间会置为默认 (默认为 UTC: 2024-01-01 00:00:00)
+//
+// 注: 参数错误会 panic, 不希望 panic 请使用 NewE
 func New(dataCenterId, workerId uint64, start ...time.Time) *IdGenerator {
-	if dataCenterId > dataCenterIdMaxValue {
-		panic(fmt.Sprintf("雪花算法 id 生成器 dataCenterId 范围应为 [0, %d]", dataCenterIdMaxValue))
+	return NewWithLayout(LayoutTwitter, dataCenterId, workerId, start...)
+}
+
+// 创建ID生成器实例 (默认 LayoutTwitter 布局), 参数同 New
+func NewE(dataCenterId, workerId uint64, start ...time.Time) (*IdGenerator, error) {
+	return NewWithLayoutE(LayoutTwitter, dataCenterId, workerId, start...)
+}
+
+// 创建指定位布局的ID生成器实例
+//   - {layout} 位布局, 可用预设 LayoutTwitter, LayoutSonyflake, LayoutBaidu
+//   - {dataCenterId} 集群ID [0, layout.MaxDataCenterId()]
+//   - {workerId} 程序ID [0, layout.MaxWorkerId()]
+//   - {start} 可选, 设置起始点, 未来时间会置为默认 (默认为 UTC: 2024-01-01 00:00:00)
+//
+// 注: 参数错误会 panic, 不希望 panic 请使用 NewWithLayoutE
+func NewWithLayout(layout Layout, dataCenterId, workerId uint64, start ...time.Time) *IdGenerator {
+	ig, err := NewWithLayoutE(layout, dataCenterId, workerId, start...)
+	if err != nil {
+		panic(fmt.Sprintf("雪花算法 id 生成器 %s", err))
+	}
+	return ig
+}
+
+// 创建指定位布局的ID生成器实例, 参数同 NewWithLayout
+func NewWithLayoutE(layout Layout, dataCenterId, workerId uint64, start ...time.Time) (*IdGenerator, error) {
+	if err := layout.Validate(); err != nil {
+		return nil, err
 	}
-	if workerId > workerIdMaxValue {
-		panic(fmt.Sprintf("雪花算法 id 生成器 workId 范围应为 [0, %d]", workerIdMaxValue))
+	if dataCenterId > layout.MaxDataCenterId() {
+		return nil, fmt.Errorf("%w: dataCenterId 范围应为 [0, %d]", ErrInvalidNode, layout.MaxDataCenterId())
 	}
-	realEpoch := time.Date(2024, time.January, 01, 00, 00, 00, 00, time.UTC).UnixMilli()
+	if workerId > layout.MaxWorkerId() {
+		return nil, fmt.Errorf("%w: workId 范围应为 [0, %d]", ErrInvalidNode, layout.MaxWorkerId())
+	}
+	realEpoch := defaultEpoch
 	if len(start) != 0 && start[0].Before(time.Now()) {
-		realEpoch = start[0].UnixMilli()
+		realEpoch = start[0]
 	}
-	return &IdGenerator{
-		epoch:        realEpoch,
+	ig := &IdGenerator{
+		epoch:        layout.toTick(realEpoch),
 		timestamp:    defaultInitValue - 1,
 		sequence:     defaultInitValue,
 		dataCenterId: dataCenterId,
 		workerId:     workerId,
 
+		layout:  layout,
+		clock:   time.Now,
+		created: time.Now(),
+
 		mu: new(sync.Mutex),
 	}
+	ig.SetRollbackPolicy(RollbackWait(900 * time.Millisecond))
+	return ig, nil
+}
+
+// SetRollbackPolicy 设置时钟回拨处理策略 (默认 RollbackWait 最多等待 900ms)
+//
+// 可在生成ID期间调用, 正在进行的生成仍使用调用前的策略
+func (ig *IdGenerator) SetRollbackPolicy(policy RollbackPolicy) *IdGenerator {
+	ig.rollback.Store(&policy)
+	return ig
+}
+
+// SetClock 设置时间源 (默认 time.Now), 多用于测试
+func (ig *IdGenerator) SetClock(clock func() time.Time) *IdGenerator {
+	ig.mu.Lock()
+	defer ig.mu.Unlock()
+	ig.clock = clock
+	return ig
+}
+
+// Layout 当前位布局
+func (ig *IdGenerator) Layout() Layout {
+	return ig.layout
+}
+
+// Epoch 当前起始点
+func (ig *IdGenerator) Epoch() time.Time {
+	return ig.layout.fromTick(ig.epoch)
+}
+
+// Close 释放程序ID (仅 NewWithProvider 创建的实例需要), 释放后不可再生成ID
+func (ig *IdGenerator) Close() error {
+	if ig.provider == nil {
+		return nil
+	}
+	ig.closed.Store(true)
+	return ig.provider.Release(context.Background())
 }
 
 // Gen 生成ID (10进制位)
@@ -98,61 +181,148 @@ func (ig *IdGenerator) GenB36() (string, string) {
 	return strconv.FormatUint(id, 36), fmt.Sprintf("%d", id)
 }
 
+// GenEnc 生成雪花算法ID (指定编码, 10进制位)
+//   - {enc} 编码, 如 Base62, Base32Crockford.WithCheck()
+func (ig *IdGenerator) GenEnc(enc *Encoding) (string, string) {
+	id := ig.genId()
+	return enc.Encode(id), fmt.Sprintf("%d", id)
+}
+
 // GenNum 生成雪花算法ID (10进制位数值)
 //
 //	注: snowflake设计用到63位二进制位、需要数值必须uint64类型表示
 //	    但需注意存数据库/传前端能否正确表示、业务逻辑中也尽量不转非uint64类型
+//	    可使用 GenID 返回自带 JSON/数据库转换的 ID 类型
 func (ig *IdGenerator) GenNum() uint64 {
 	return ig.genId()
 }
 
+// GenE 生成雪花算法ID (10进制位数值)
+//
+// 与 GenNum 相同, 但出错时返回错误而非 panic, 可能的错误:
+//   - ErrClockBackwards 时钟回拨且回拨策略无法处理
+//   - ErrTimeOverflow 超出位布局时间戳期限
+//   - ErrLeaseLost 程序ID 租约已丢失
+//   - ErrStatePersist 持久化高水位时间失败 (设置了 StateStore 时)
+func (ig *IdGenerator) GenE() (uint64, error) {
+	return ig.genIdE()
+}
+
+// GenBatch 批量生成雪花算法ID (10进制位数值)
+//
+// 每次在同一时间单位内一次性预留剩余的连续序列号, 比循环调用 GenE 加锁/CAS 次数少,
+// 返回的ID单调递增; {n} 为 0 时返回空切片, 小于 0 或出错时返回 nil 及错误, 其余错误同 GenE
+func (ig *IdGenerator) GenBatch(n int) ([]uint64, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("idgen: 批量生成数量不能为负数 %d", n)
+	}
+	ids := make([]uint64, 0, n)
+	for len(ids) < n {
+		first, count, err := ig.reserve(uint64(n - len(ids)))
+		if err != nil {
+			return nil, err
+		}
+		for i := uint64(0); i < count; i++ {
+			ids = append(ids, first+i)
+		}
+	}
+	return ids, nil
+}
+
 func (ig *IdGenerator) genId() uint64 {
+	id, err := ig.genIdE()
+	if err != nil {
+		panic(fmt.Sprintf("雪花算法 %s", err))
+	}
+	return id
+}
+
+func (ig *IdGenerator) genIdE() (uint64, error) {
+	id, _, err := ig.reserve(1)
+	return id, err
+}
+
+// reserve 预留至多 {n} 个连续ID (同一时间单位内), 返回首个ID与实际预留数量
+func (ig *IdGenerator) reserve(n uint64) (uint64, uint64, error) {
+	if err := ig.leaseErr(); err != nil {
+		return 0, 0, err
+	}
+	if ig.lockFree.Load() {
+		return ig.reserveAtomic(n)
+	}
+
 	ig.mu.Lock()
 	defer ig.mu.Unlock()
 
-	var now = time.Now().UnixMilli()
+	var now = ig.layout.toTick(ig.clock())
+
+	policy := ig.rollback.Load()
 
 	// 时钟回拨处理
 	if ig.timestamp > now {
-		// 方式一: 直接抛异常
-		// panic(fmt.Sprintf("雪花算法 时钟回拨 最后时间戳 %d, 比较时间戳 %d", ig.timestamp, now))
-
-		// 方式二: 延迟等待三次
-		for i := 0; i < 3; i++ {
-			time.Sleep(time.Millisecond * 300) //期望时钟自身校正
-			now = time.Now().UnixMilli()
-			if ig.timestamp <= now {
-				break
-			}
-		}
-		if ig.timestamp > now {
-			panic(fmt.Sprintf("雪花算法 时钟回拨 最后时间戳 %d, 比较时间戳 %d", ig.timestamp, now))
+		ig.rollbacks.Add(1)
+		var err error
+		if now, err = ig.handleRollback(now, policy); err != nil {
+			return 0, 0, err
 		}
 	}
 
+	var first uint64 = defaultInitValue
 	if ig.timestamp == now {
-		// 相同时间戳、序列号自旋
-		ig.sequence = (ig.sequence + 1) & sequenceMaxValue //递增序列号
-		if ig.sequence == 0 {
-			// 序列号溢出4095、等待至下一毫秒
+		if ig.sequence < ig.layout.MaxSequence() {
+			// 相同时间戳、序列号递增
+			first = ig.sequence + 1
+		} else {
+			// 序列号溢出、借用下一时间单位或等待至下一时间单位
+			ig.overflows.Add(1)
+			if policy.mode == rollbackBorrow {
+				if ig.borrowable(now+1, policy) {
+					now++
+				} else if ig.layout.toTick(ig.clock()) < ig.timestamp {
+					return 0, 0, fmt.Errorf("%w: 借用时间超出上限 %s", ErrClockBackwards, policy.max)
+				}
+			}
 			for now <= ig.timestamp {
-				now = time.Now().UnixMilli()
+				now = ig.layout.toTick(ig.clock())
 			}
 		}
-	} else {
-		// 时间戳进位、序列号重置
-		ig.sequence = defaultInitValue
 	}
+	// 时间戳进位时序列号从 0 开始
 
 	diff := uint64(now - ig.epoch)
-	if diff > timestampMaxValue {
-		// 运行超 69 年期限、直接抛异常
-		panic(fmt.Sprintf("雪花算法 起始时间 epoch 范围应为 [0, %d]", timestampMaxValue-1))
+	if diff > bitsMax(ig.layout.TimeBits) {
+		// 运行超出布局时间戳期限
+		return 0, 0, fmt.Errorf("%w: 起始时间 epoch 范围应为 [0, %d]", ErrTimeOverflow, bitsMax(ig.layout.TimeBits)-1)
+	}
+	if err := ig.ensureReserved(now); err != nil {
+		return 0, 0, err
 	}
+	count := min(n, ig.layout.MaxSequence()-first+1)
 	ig.timestamp = now
+	ig.sequence = first + count - 1
+	ig.generated.Add(count)
+
+	return ig.compose(diff, ig.rollbackSeq, first), count, nil
+}
+
+// leaseErr 程序ID 已释放或租约丢失时返回 ErrLeaseLost
+func (ig *IdGenerator) leaseErr() error {
+	if ig.closed.Load() {
+		return fmt.Errorf("%w: 程序ID %d 已释放", ErrLeaseLost, ig.workerId)
+	}
+	select {
+	case <-ig.lost:
+		return fmt.Errorf("%w: 程序ID %d", ErrLeaseLost, ig.workerId)
+	default:
+	}
+	return nil
+}
 
-	return (diff << timestampShift) |
-		(ig.dataCenterId << dataCenterIdShift) |
-		(ig.workerId << workIdShift) |
-		ig.sequence
+// compose 组装ID
+func (ig *IdGenerator) compose(diff, rollbackSeq, sequence uint64) uint64 {
+	return (diff << ig.layout.timeShift()) |
+		(ig.dataCenterId << ig.layout.dataCenterShift()) |
+		(ig.workerId << ig.layout.workerShift()) |
+		(rollbackSeq << ig.layout.rollbackShift()) |
+		sequence
 }
@@ -0,0 +1,146 @@
+package idgen
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrStatePersist 持久化高水位时间失败
+var ErrStatePersist = errors.New("idgen: persist state failed")
+
+// StateStore 生成器状态存储
+//
+// 用于持久化已预留的高水位时间, 重启后不会生成早于该时间的ID,
+// 避免进程在时钟回拨期间重启导致ID重复
+type StateStore interface {
+	// Load 读取高水位时间, 无记录时返回零值
+	Load() (time.Time, error)
+	// Save 保存高水位时间
+	Save(t time.Time) error
+}
+
+// SetStateStore 设置状态存储
+//   - {store} 状态存储, 可用 NewFileStateStore
+//   - {window} 可选, 每次预留的时间窗口, 默认 500ms
+//
+// 生成ID前会先持久化 "当前时间+window" 作为高水位 (剩余不足一半窗口时后台续期),
+// 因此最多每 window/2 写入一次; 设置时读取到的高水位晚于当前时间的部分按时钟回拨策略处理,
+// 故 window 应小于 RollbackWait 的等待时间, 或使用 RollbackBorrow 策略
+//
+// 注: 应在生成ID前设置
+func (ig *IdGenerator) SetStateStore(store StateStore, window ...time.Duration) error {
+	last, err := store.Load()
+	if err != nil {
+		return err
+	}
+	w := 500 * time.Millisecond
+	if len(window) != 0 && window[0] > 0 {
+		w = window[0]
+	}
+
+	ig.mu.Lock()
+	defer ig.mu.Unlock()
+	ig.stateStore = store
+	ig.stateWindow = max(int64(w/ig.layout.TimeUnit), 1)
+	if last.IsZero() {
+		return nil
+	}
+	tick := ig.layout.toTick(last)
+	ig.reservedUntil.Store(tick)
+	if tick > ig.timestamp {
+		// 从高水位继续, 序列号置为最大值使下一ID进入新的时间单位
+		ig.timestamp = tick
+		ig.sequence = ig.layout.MaxSequence()
+		if ig.lockFree.Load() && tick >= ig.epoch {
+			l := ig.layout
+			ig.state.Store(uint64(tick-ig.epoch)<<(l.RollbackBits+l.SequenceBits) |
+				ig.rollbackSeq<<l.rollbackShift() |
+				l.MaxSequence())
+		}
+	}
+	return nil
+}
+
+// ensureReserved 确保时间戳 {tick} 已在持久化的高水位之内
+func (ig *IdGenerator) ensureReserved(tick int64) error {
+	if ig.stateStore == nil {
+		return nil
+	}
+	until := ig.reservedUntil.Load()
+	if tick < until {
+		if tick >= until-ig.stateWindow/2 && ig.saving.CompareAndSwap(false, true) {
+			go func() {
+				defer ig.saving.Store(false)
+				_ = ig.saveState(tick)
+			}()
+		}
+		return nil
+	}
+	return ig.saveState(tick)
+}
+
+func (ig *IdGenerator) saveState(tick int64) error {
+	ig.persistMu.Lock()
+	defer ig.persistMu.Unlock()
+	mark := tick + ig.stateWindow
+	if mark <= ig.reservedUntil.Load() {
+		return nil
+	}
+	if err := ig.stateStore.Save(ig.layout.fromTick(mark)); err != nil {
+		return fmt.Errorf("%w: %w", ErrStatePersist, err)
+	}
+	ig.reservedUntil.Store(mark)
+	return nil
+}
+
+// ============================================================
+
+// FileStateStore 文件状态存储, 内容为高水位毫秒时间戳
+type FileStateStore struct {
+	path string
+}
+
+// NewFileStateStore 创建文件状态存储
+//   - {path} 文件路径, 各生成器实例 (集群ID+程序ID) 应使用不同文件
+func NewFileStateStore(path string) *FileStateStore {
+	return &FileStateStore{path: path}
+}
+
+func (s *FileStateStore) Load() (time.Time, error) {
+	b, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+	ms, err := strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("idgen: 状态文件 %s 格式错误: %w", s.path, err)
+	}
+	return time.UnixMilli(ms), nil
+}
+
+// Save 先写临时文件再重命名, 保证文件内容完整
+func (s *FileStateStore) Save(t time.Time) error {
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.WriteString(strconv.FormatInt(t.UnixMilli(), 10)); err == nil {
+		err = tmp.Sync()
+	}
+	if cErr := tmp.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
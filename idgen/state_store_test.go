@@ -0,0 +1,51 @@
+package idgen_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ackcoder/go-mods/idgen"
+)
+
+func TestStateStore(t *testing.T) {
+	store := idgen.NewFileStateStore(filepath.Join(t.TempDir(), "idgen-1-1.state"))
+
+	ig1 := idgen.New(1, 1)
+	if err := ig1.SetStateStore(store, 200*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	var last uint64
+	for i := 0; i < 1000; i++ {
+		last = ig1.GenNum()
+	}
+	mark, err := store.Load()
+	if err != nil || mark.Before(ig1.Parse(last).Time) {
+		t.Fatal("高水位应不早于最后生成时间", mark, err)
+	}
+
+	// 模拟时钟回拨期间重启, 拒绝生成早于高水位的ID
+	ig2 := idgen.New(1, 1).
+		SetClock(func() time.Time { return time.Now().Add(-time.Hour) }).
+		SetRollbackPolicy(idgen.RollbackFail())
+	if err = ig2.SetStateStore(store); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ig2.GenE(); !errors.Is(err, idgen.ErrClockBackwards) {
+		t.Error("早于高水位理应报错", err)
+	}
+
+	// 正常重启, 等待至高水位后继续生成
+	ig3 := idgen.New(1, 1).SetLockFree(true)
+	if err = ig3.SetStateStore(store); err != nil {
+		t.Fatal(err)
+	}
+	v, err := ig3.GenE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v <= last {
+		t.Error("重启后 id 应大于重启前", v, last)
+	}
+}
@@ -0,0 +1,87 @@
+package idgen
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// crockfordAlphabet Crockford Base32 字符表 (去除 I L O U)
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var crockfordIndex = crockfordIndexWithAliases()
+
+// ULID 128位 = 48位毫秒时间戳 + 80位随机数, 字串形式为26位 Crockford Base32
+//
+// 规范: https://github.com/ulid/spec
+type ULID [16]byte
+
+// ParseULID 解析 ULID 字串 (不区分大小写, 兼容 I/L->1, O->0)
+func ParseULID(s string) (ULID, error) {
+	var u ULID
+	if len(s) != 26 || decodeBits(s, u[:], crockfordIndex) != nil {
+		return u, fmt.Errorf("%w: ulid %q", ErrInvalidFormat, s)
+	}
+	return u, nil
+}
+
+func (u ULID) String() string {
+	return encodeBits(u[:], 26, crockfordAlphabet)
+}
+
+func (u ULID) Bytes() []byte {
+	return u[:]
+}
+
+// Time 生成时间 (毫秒精度)
+func (u ULID) Time() time.Time {
+	ms := int64(u[0])<<40 | int64(u[1])<<32 | int64(u[2])<<24 | int64(u[3])<<16 | int64(u[4])<<8 | int64(u[5])
+	return time.UnixMilli(ms)
+}
+
+// ============================================================
+
+// ULIDGenerator ULID 生成器
+//
+// 同一毫秒内随机数部分递增加一以保证单调递增, 溢出时返回 ErrMonotonicOverflow
+type ULIDGenerator struct {
+	mu      sync.Mutex
+	lastMs  int64
+	entropy [10]byte
+
+	clock func() time.Time
+}
+
+func NewULID() *ULIDGenerator {
+	return &ULIDGenerator{clock: time.Now}
+}
+
+func (g *ULIDGenerator) Next() (Identifier, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := g.clock().UnixMilli()
+	if ms <= g.lastMs {
+		ms = g.lastMs
+		if incrBytes(g.entropy[:]) {
+			return nil, ErrMonotonicOverflow
+		}
+	} else if err := randRead(g.entropy[:]); err != nil {
+		return nil, err
+	}
+	g.lastMs = ms
+
+	var u ULID
+	u[0] = byte(ms >> 40)
+	u[1] = byte(ms >> 32)
+	u[2] = byte(ms >> 24)
+	u[3] = byte(ms >> 16)
+	u[4] = byte(ms >> 8)
+	u[5] = byte(ms)
+	copy(u[6:], g.entropy[:])
+	return u, nil
+}
+
+func (g *ULIDGenerator) Parse(s string) (Identifier, error) {
+	return ParseULID(s)
+}
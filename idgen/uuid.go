@@ -0,0 +1,152 @@
+package idgen
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UUID RFC 9562 UUID (128位)
+type UUID [16]byte
+
+// ParseUUID 解析 UUID 字串
+//
+// 支持 "xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx", 不带"-"的32位十六进制, 以及 "urn:uuid:" 与 "{}" 包裹形式
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	s = strings.TrimPrefix(s, "urn:uuid:")
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "{"), "}")
+	switch len(s) {
+	case 36:
+		if s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+			return u, fmt.Errorf("%w: uuid %q", ErrInvalidFormat, s)
+		}
+		s = s[:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	case 32:
+	default:
+		return u, fmt.Errorf("%w: uuid %q", ErrInvalidFormat, s)
+	}
+	if _, err := hex.Decode(u[:], []byte(s)); err != nil {
+		return u, fmt.Errorf("%w: uuid %q", ErrInvalidFormat, s)
+	}
+	return u, nil
+}
+
+// String 标准字串形式 "xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx"
+func (u UUID) String() string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
+
+func (u UUID) Bytes() []byte {
+	return u[:]
+}
+
+// Version 版本号
+func (u UUID) Version() int {
+	return int(u[6] >> 4)
+}
+
+// Time 生成时间, 仅 UUIDv7 有效 (毫秒精度)
+func (u UUID) Time() time.Time {
+	if u.Version() != 7 {
+		return time.Time{}
+	}
+	ms := int64(u[0])<<40 | int64(u[1])<<32 | int64(u[2])<<24 | int64(u[3])<<16 | int64(u[4])<<8 | int64(u[5])
+	return time.UnixMilli(ms)
+}
+
+// setVersion 设置版本号与变体位 (RFC 9562)
+func (u *UUID) setVersion(ver byte) {
+	u[6] = u[6]&0x0F | ver<<4
+	u[8] = u[8]&0x3F | 0x80
+}
+
+// ============================================================
+
+// UUIDv4Generator UUIDv4 (随机) 生成器
+type UUIDv4Generator struct{}
+
+func NewUUIDv4() *UUIDv4Generator {
+	return &UUIDv4Generator{}
+}
+
+func (g *UUIDv4Generator) Next() (Identifier, error) {
+	var u UUID
+	if err := randRead(u[:]); err != nil {
+		return nil, err
+	}
+	u.setVersion(4)
+	return u, nil
+}
+
+func (g *UUIDv4Generator) Parse(s string) (Identifier, error) {
+	return ParseUUID(s)
+}
+
+// ============================================================
+
+// UUIDv7Generator UUIDv7 (毫秒时间戳+随机) 生成器
+//
+// 同一毫秒内以 rand_a 的12位作为计数器保证单调递增 (RFC 9562 6.2 方法一), 计数器溢出时借用下一毫秒
+type UUIDv7Generator struct {
+	mu      sync.Mutex
+	lastMs  int64
+	counter uint16
+
+	clock func() time.Time
+}
+
+func NewUUIDv7() *UUIDv7Generator {
+	return &UUIDv7Generator{clock: time.Now}
+}
+
+func (g *UUIDv7Generator) Next() (Identifier, error) {
+	var u UUID
+	if err := randRead(u[6:]); err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	ms := g.clock().UnixMilli()
+	if ms <= g.lastMs {
+		ms = g.lastMs
+		g.counter++
+		if g.counter > 0x0FFF {
+			ms++
+			g.counter = uint16(u[7]) & 0x07FF //随机起始值, 保留一半空间用于递增
+		}
+	} else {
+		g.counter = uint16(u[6]&0x0F)<<8 | uint16(u[7])
+		g.counter &= 0x07FF
+	}
+	g.lastMs = ms
+	counter := g.counter
+	g.mu.Unlock()
+
+	u[0] = byte(ms >> 40)
+	u[1] = byte(ms >> 32)
+	u[2] = byte(ms >> 24)
+	u[3] = byte(ms >> 16)
+	u[4] = byte(ms >> 8)
+	u[5] = byte(ms)
+	u[6] = byte(counter >> 8)
+	u[7] = byte(counter)
+	u.setVersion(7)
+	return u, nil
+}
+
+func (g *UUIDv7Generator) Parse(s string) (Identifier, error) {
+	return ParseUUID(s)
+}
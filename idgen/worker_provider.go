@@ -0,0 +1,476 @@
+package idgen
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ackcoder/go-mods/utils"
+)
+
+var (
+	// ErrNoWorkerId 无可用程序ID (均已被占用)
+	ErrNoWorkerId = errors.New("idgen: no available worker id")
+	// ErrLeaseLost 程序ID 租约已丢失
+	ErrLeaseLost = errors.New("idgen: worker id lease lost")
+)
+
+// WorkerIDProvider 程序ID 提供者
+//
+// 用于自动分配程序ID, 避免多实例手动配置重复导致ID冲突
+type WorkerIDProvider interface {
+	// Acquire 获取程序ID
+	//   - {maxId} 程序ID 最大值, 即 layout.MaxWorkerId()
+	Acquire(ctx context.Context, maxId uint64) (uint64, error)
+	// Release 释放程序ID (程序退出时调用), 释放后应关闭 Lost 通道
+	Release(ctx context.Context) error
+	// Lost 租约丢失通知, 通道关闭即表示程序ID 可能已被其他实例占用
+	//
+	//	注: 无租约概念的提供者返回 nil 即可
+	Lost() <-chan struct{}
+}
+
+// 通过程序ID提供者创建ID生成器实例
+//   - {provider} 程序ID提供者
+//   - {layout} 位布局
+//   - {dataCenterId} 集群ID [0, layout.MaxDataCenterId()]
+//   - {start} 可选, 设置起始点, 未来时间会置为默认 (默认为 UTC: 2024-01-01 00:00:00)
+//
+// 注: 程序退出时需调用 Close 释放程序ID; 租约丢失或 Close 后 GenE 返回 ErrLeaseLost, 其余生成方法会 panic
+func NewWithProvider(ctx context.Context, provider WorkerIDProvider, layout Layout, dataCenterId uint64, start ...time.Time) (*IdGenerator, error) {
+	if err := layout.Validate(); err != nil {
+		return nil, err
+	}
+	if dataCenterId > layout.MaxDataCenterId() {
+		return nil, fmt.Errorf("%w: dataCenterId 范围应为 [0, %d]", ErrInvalidNode, layout.MaxDataCenterId())
+	}
+	workerId, err := provider.Acquire(ctx, layout.MaxWorkerId())
+	if err != nil {
+		return nil, err
+	}
+	ig, err := NewWithLayoutE(layout, dataCenterId, workerId, start...)
+	if err != nil {
+		_ = provider.Release(ctx)
+		return nil, err
+	}
+	ig.provider = provider
+	ig.lost = provider.Lost()
+	return ig, nil
+}
+
+// ============================================================
+
+// HostSource 主机程序ID 推导来源
+type HostSource int
+
+const (
+	HostByIPv4 HostSource = iota //取首个 IPv4 地址低位 (适合同网段内容器/Pod)
+	HostByMac                    //取首个网卡 MAC 地址哈希
+)
+
+// HostProvider 根据主机网络信息推导程序ID
+//
+//	注: 无租约机制, IPv4 低位超出程序ID 位数或 MAC 哈希取模时仍可能冲突
+type HostProvider struct {
+	source HostSource
+}
+
+// NewHostProvider 创建主机程序ID提供者
+//   - {source} 推导来源 HostByIPv4/HostByMac
+func NewHostProvider(source HostSource) *HostProvider {
+	return &HostProvider{source: source}
+}
+
+func (p *HostProvider) Acquire(_ context.Context, maxId uint64) (uint64, error) {
+	list, err := utils.NetworkInfoList()
+	if err != nil {
+		return 0, err
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	for _, info := range list {
+		switch p.source {
+		case HostByIPv4:
+			if len(info.Ipv4) == 0 {
+				continue
+			}
+			ip := net.ParseIP(info.Ipv4[0]).To4()
+			if ip == nil {
+				continue
+			}
+			v := uint64(ip[0])<<24 | uint64(ip[1])<<16 | uint64(ip[2])<<8 | uint64(ip[3])
+			return v & maxId, nil
+		case HostByMac:
+			h := fnv.New64a()
+			h.Write([]byte(info.Mac))
+			return h.Sum64() % (maxId + 1), nil
+		}
+	}
+	return 0, ErrNoWorkerId
+}
+
+func (p *HostProvider) Release(context.Context) error { return nil }
+
+func (p *HostProvider) Lost() <-chan struct{} { return nil }
+
+// ============================================================
+
+// FileLeaseProvider 基于共享目录文件租约的程序ID提供者
+//
+// 每个程序ID 对应目录下 "worker-{id}.{gen}.lease" 文件, 代数 gen 最大的文件为当前租约;
+// 以 O_EXCL 方式创建下一代文件即占用成功 (同一代文件只能被一个实例创建), 持有期间按 ttl/3 周期刷新文件修改时间,
+// 超过 ttl 未刷新的租约视为过期, 可被其他实例以创建下一代文件的方式接管
+//
+//	注: 适用于 NFS 等共享存储 (不依赖 flock), 需保证各实例时钟基本一致
+type FileLeaseProvider struct {
+	dir string
+	ttl time.Duration
+
+	id   uint64
+	gen  uint64
+	path string
+	*lease
+}
+
+// fileLease 程序ID 的当前租约文件
+type fileLease struct {
+	gen     uint64
+	modTime time.Time
+}
+
+// NewFileLeaseProvider 创建文件租约程序ID提供者
+//   - {dir} 租约文件目录, 不存在时自动创建
+//   - {ttl} 租约有效期, 默认 30s
+func NewFileLeaseProvider(dir string, ttl time.Duration) *FileLeaseProvider {
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+	return &FileLeaseProvider{dir: dir, ttl: ttl, lease: newLease()}
+}
+
+func (p *FileLeaseProvider) Acquire(_ context.Context, maxId uint64) (uint64, error) {
+	if err := os.MkdirAll(p.dir, 0o755); err != nil {
+		return 0, err
+	}
+	leases, err := p.scan()
+	if err != nil {
+		return 0, err
+	}
+	for id := uint64(0); id <= maxId; id++ {
+		var gen uint64
+		if cur, ok := leases[id]; ok {
+			if time.Since(cur.modTime) < p.ttl {
+				continue
+			}
+			gen = cur.gen + 1 //过期接管
+		}
+		ok, err := p.claim(id, gen)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			continue
+		}
+		p.start(p.ttl, p.refresh)
+		return id, nil
+	}
+	return 0, ErrNoWorkerId
+}
+
+// Release 释放程序ID
+//
+//	注: 不删除租约文件 (保证代数只增不减), 仅将其修改时间置为过期
+func (p *FileLeaseProvider) Release(context.Context) error {
+	p.stop()
+	if p.path == "" {
+		return nil
+	}
+	if cur, ok, err := p.current(p.id); err != nil || !ok || cur.gen != p.gen {
+		return err
+	}
+	return os.Chtimes(p.path, time.Unix(0, 0), time.Unix(0, 0))
+}
+
+func (p *FileLeaseProvider) leasePath(id, gen uint64) string {
+	return filepath.Join(p.dir, fmt.Sprintf("worker-%d.%d.lease", id, gen))
+}
+
+// claim 创建第 {gen} 代租约文件
+//
+// 视图过旧的实例可能在较新一代已存在时创建了较早的一代 (旧代文件被清理后),
+// 因此创建后需确认自己仍是最新一代
+func (p *FileLeaseProvider) claim(id, gen uint64) (bool, error) {
+	path := p.leasePath(id, gen)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	_, err = f.WriteString(p.owner)
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return false, err
+	}
+	cur, ok, err := p.current(id)
+	if err != nil || !ok || cur.gen != gen {
+		_ = os.Remove(path)
+		return false, err
+	}
+	// 清理旧代文件
+	for old := range gen {
+		_ = os.Remove(p.leasePath(id, old))
+	}
+	p.id, p.gen, p.path = id, gen, path
+	return true, nil
+}
+
+// scan 读取目录下各程序ID 的当前租约
+func (p *FileLeaseProvider) scan() (map[uint64]fileLease, error) {
+	entries, err := os.ReadDir(p.dir)
+	if err != nil {
+		return nil, err
+	}
+	leases := make(map[uint64]fileLease)
+	for _, entry := range entries {
+		id, gen, ok := parseLeaseName(entry.Name())
+		if !ok {
+			continue
+		}
+		if cur, exist := leases[id]; exist && cur.gen > gen {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue //刚被清理
+			}
+			return nil, err
+		}
+		leases[id] = fileLease{gen: gen, modTime: info.ModTime()}
+	}
+	return leases, nil
+}
+
+// current 程序ID {id} 的当前租约
+func (p *FileLeaseProvider) current(id uint64) (fileLease, bool, error) {
+	leases, err := p.scan()
+	if err != nil {
+		return fileLease{}, false, err
+	}
+	cur, ok := leases[id]
+	return cur, ok, nil
+}
+
+// parseLeaseName 解析租约文件名 "worker-{id}.{gen}.lease"
+func parseLeaseName(name string) (id, gen uint64, ok bool) {
+	name, ok1 := strings.CutPrefix(name, "worker-")
+	name, ok2 := strings.CutSuffix(name, ".lease")
+	ids, gens, ok3 := strings.Cut(name, ".")
+	if !ok1 || !ok2 || !ok3 {
+		return 0, 0, false
+	}
+	id, err1 := strconv.ParseUint(ids, 10, 64)
+	gen, err2 := strconv.ParseUint(gens, 10, 64)
+	return id, gen, err1 == nil && err2 == nil
+}
+
+func (p *FileLeaseProvider) refresh() (bool, error) {
+	cur, ok, err := p.current(p.id)
+	if err != nil {
+		return false, err
+	}
+	if !ok || cur.gen != p.gen {
+		return false, nil //已被接管或文件被删除
+	}
+	now := time.Now()
+	return true, os.Chtimes(p.path, now, now)
+}
+
+// ============================================================
+
+// LeaseStore 租约键值存储 (可对接 Redis/Etcd 等)
+type LeaseStore interface {
+	// SetNX 键不存在(或已过期)时设置值与有效期, 返回是否设置成功
+	SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+	// Refresh 键值匹配时续期, 返回是否续期成功 (不匹配即租约已丢失)
+	Refresh(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+	// Delete 键值匹配时删除
+	Delete(ctx context.Context, key, value string) error
+}
+
+// KVLeaseProvider 基于键值存储租约的程序ID提供者
+//
+// 每个程序ID 对应键 "{prefix}{id}", 持有期间按 ttl/3 周期续期
+type KVLeaseProvider struct {
+	store  LeaseStore
+	prefix string
+	ttl    time.Duration
+
+	key string
+	*lease
+}
+
+// NewKVLeaseProvider 创建键值存储租约程序ID提供者
+//   - {store} 租约存储
+//   - {prefix} 键前缀, 如 "idgen:order:worker:"
+//   - {ttl} 租约有效期, 默认 30s
+func NewKVLeaseProvider(store LeaseStore, prefix string, ttl time.Duration) *KVLeaseProvider {
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+	return &KVLeaseProvider{store: store, prefix: prefix, ttl: ttl, lease: newLease()}
+}
+
+func (p *KVLeaseProvider) Acquire(ctx context.Context, maxId uint64) (uint64, error) {
+	for id := uint64(0); id <= maxId; id++ {
+		key := fmt.Sprintf("%s%d", p.prefix, id)
+		ok, err := p.store.SetNX(ctx, key, p.owner, p.ttl)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			continue
+		}
+		p.key = key
+		p.start(p.ttl, func() (bool, error) {
+			ctx, cancel := context.WithTimeout(context.Background(), p.ttl/3)
+			defer cancel()
+			return p.store.Refresh(ctx, p.key, p.owner, p.ttl)
+		})
+		return id, nil
+	}
+	return 0, ErrNoWorkerId
+}
+
+func (p *KVLeaseProvider) Release(ctx context.Context) error {
+	p.stop()
+	if p.key == "" {
+		return nil
+	}
+	return p.store.Delete(ctx, p.key, p.owner)
+}
+
+// MemoryLeaseStore 内存租约存储 (仅限单进程内使用, 多用于测试)
+type MemoryLeaseStore struct {
+	mu    sync.Mutex
+	items map[string]memoryLeaseItem
+}
+
+type memoryLeaseItem struct {
+	value    string
+	expireAt time.Time
+}
+
+func NewMemoryLeaseStore() *MemoryLeaseStore {
+	return &MemoryLeaseStore{items: make(map[string]memoryLeaseItem)}
+}
+
+func (s *MemoryLeaseStore) SetNX(_ context.Context, key, value string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if item, ok := s.items[key]; ok && time.Now().Before(item.expireAt) {
+		return false, nil
+	}
+	s.items[key] = memoryLeaseItem{value: value, expireAt: time.Now().Add(ttl)}
+	return true, nil
+}
+
+func (s *MemoryLeaseStore) Refresh(_ context.Context, key, value string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.items[key]
+	if !ok || item.value != value || time.Now().After(item.expireAt) {
+		return false, nil
+	}
+	s.items[key] = memoryLeaseItem{value: value, expireAt: time.Now().Add(ttl)}
+	return true, nil
+}
+
+func (s *MemoryLeaseStore) Delete(_ context.Context, key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if item, ok := s.items[key]; ok && item.value == value {
+		delete(s.items, key)
+	}
+	return nil
+}
+
+// ============================================================
+
+// lease 租约续期公共逻辑
+type lease struct {
+	owner string //当前实例持有者标识
+
+	done     chan struct{}
+	lost     chan struct{}
+	stopOnce sync.Once
+	lostOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+func newLease() *lease {
+	host, _ := os.Hostname()
+	buf := make([]byte, 8)
+	_, _ = rand.Read(buf)
+	return &lease{
+		owner: fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(buf)),
+		done:  make(chan struct{}),
+		lost:  make(chan struct{}),
+	}
+}
+
+func (l *lease) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// start 启动续期协程
+//   - {refresh} 续期方法, 返回 false 表示租约已被他人占用
+//
+// 续期出错时持续重试, 超过 ttl 仍未成功则视为租约丢失
+func (l *lease) start(ttl time.Duration, refresh func() (bool, error)) {
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+		lastOk := time.Now()
+		for {
+			select {
+			case <-l.done:
+				return
+			case <-ticker.C:
+			}
+			ok, err := refresh()
+			if err == nil && !ok || err != nil && time.Since(lastOk) >= ttl {
+				l.lostOnce.Do(func() { close(l.lost) })
+				return
+			}
+			if err == nil {
+				lastOk = time.Now()
+			}
+		}
+	}()
+}
+
+// stop 停止续期, 释放后程序ID 可被其他实例占用, 同样视为租约丢失
+func (l *lease) stop() {
+	l.stopOnce.Do(func() { close(l.done) })
+	l.wg.Wait()
+	l.lostOnce.Do(func() { close(l.lost) })
+}
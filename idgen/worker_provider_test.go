@@ -0,0 +1,148 @@
+package idgen_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ackcoder/go-mods/idgen"
+)
+
+func TestKVLeaseProvider(t *testing.T) {
+	ctx := context.Background()
+	store := idgen.NewMemoryLeaseStore()
+	layout := idgen.Layout{TimeBits: 41, TimeUnit: time.Millisecond, WorkerBits: 1, SequenceBits: 12}
+
+	ig1, err := idgen.NewWithProvider(ctx, idgen.NewKVLeaseProvider(store, "test:", time.Second), layout, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ig2, err := idgen.NewWithProvider(ctx, idgen.NewKVLeaseProvider(store, "test:", time.Second), layout, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ig1.Parse(ig1.GenNum()).WorkerId == ig2.Parse(ig2.GenNum()).WorkerId {
+		t.Error("程序ID 不应重复")
+	}
+	if _, err = idgen.NewWithProvider(ctx, idgen.NewKVLeaseProvider(store, "test:", time.Second), layout, 0); !errors.Is(err, idgen.ErrNoWorkerId) {
+		t.Error("程序ID 已用尽理应失败", err)
+	}
+
+	// 释放后可被重新占用
+	if err = ig1.Close(); err != nil {
+		t.Fatal(err)
+	}
+	ig3, err := idgen.NewWithProvider(ctx, idgen.NewKVLeaseProvider(store, "test:", time.Second), layout, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = ig2.Close()
+	_ = ig3.Close()
+}
+
+func TestFileLeaseProvider(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	p1 := idgen.NewFileLeaseProvider(dir, 300*time.Millisecond)
+	id1, err := p1.Acquire(ctx, 31)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2 := idgen.NewFileLeaseProvider(dir, 300*time.Millisecond)
+	id2, err := p2.Acquire(ctx, 31)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id1 == id2 {
+		t.Error("程序ID 不应重复", id1)
+	}
+	_ = p2.Release(ctx)
+
+	// 释放后可被立即接管
+	p3 := idgen.NewFileLeaseProvider(dir, 300*time.Millisecond)
+	if id3, err := p3.Acquire(ctx, 31); err != nil || id3 != id2 {
+		t.Error("释放的程序ID 理应可被接管", id3, err)
+	}
+	_ = p3.Release(ctx)
+
+	// 租约文件被删除后应检测到租约丢失
+	if err = os.Remove(filepath.Join(dir, "worker-0.0.lease")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-p1.Lost():
+	case <-time.After(time.Second):
+		t.Error("理应检测到租约丢失")
+	}
+	_ = p1.Release(ctx)
+}
+
+func TestFileLeaseTakeover(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	// 过期租约被多个实例同时接管, 只能有一个成功
+	stale := filepath.Join(dir, "worker-0.3.lease")
+	if err := os.WriteFile(stale, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Minute)
+	_ = os.Chtimes(stale, old, old)
+
+	var (
+		wg sync.WaitGroup
+		ok atomic.Int32
+	)
+	providers := make([]*idgen.FileLeaseProvider, 16)
+	for i := range providers {
+		providers[i] = idgen.NewFileLeaseProvider(dir, time.Second)
+		wg.Add(1)
+		go func(p *idgen.FileLeaseProvider) {
+			defer wg.Done()
+			if _, err := p.Acquire(ctx, 0); err == nil {
+				ok.Add(1)
+			} else if !errors.Is(err, idgen.ErrNoWorkerId) {
+				t.Error(err)
+			}
+		}(providers[i])
+	}
+	wg.Wait()
+	if ok.Load() != 1 {
+		t.Error("过期租约只能被一个实例接管", ok.Load())
+	}
+	for _, p := range providers {
+		_ = p.Release(ctx)
+	}
+}
+
+func TestCloseLease(t *testing.T) {
+	ctx := context.Background()
+	layout := idgen.Layout{TimeBits: 41, TimeUnit: time.Millisecond, WorkerBits: 1, SequenceBits: 12}
+	ig, err := idgen.NewWithProvider(ctx, idgen.NewKVLeaseProvider(idgen.NewMemoryLeaseStore(), "test:", time.Second), layout, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ig.GenE(); err != nil {
+		t.Fatal(err)
+	}
+	_ = ig.Close()
+	if _, err = ig.GenE(); !errors.Is(err, idgen.ErrLeaseLost) {
+		t.Error("释放后理应不可生成ID", err)
+	}
+}
+
+func TestHostProvider(t *testing.T) {
+	id, err := idgen.NewHostProvider(idgen.HostByMac).Acquire(context.Background(), 1023)
+	if err != nil {
+		t.Skip("无可用网卡", err)
+	}
+	if id > 1023 {
+		t.Error("程序ID 超出范围", id)
+	}
+}
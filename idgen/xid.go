@@ -0,0 +1,111 @@
+package idgen
+
+import (
+	"crypto/sha256"
+	"encoding/base32"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// xidEncoding XID 使用的小写 base32hex 编码 (无填充)
+var xidEncoding = base32.NewEncoding("0123456789abcdefghijklmnopqrstuv").WithPadding(base32.NoPadding)
+
+// XID 96位 = 32位秒级时间戳 + 24位机器码 + 16位进程ID + 24位计数器, 字串形式为20位 base32hex
+//
+// 规范: https://github.com/rs/xid
+type XID [12]byte
+
+// ParseXID 解析 XID 字串
+func ParseXID(s string) (XID, error) {
+	var x XID
+	if len(s) != 20 {
+		return x, fmt.Errorf("%w: xid %q", ErrInvalidFormat, s)
+	}
+	b, err := xidEncoding.DecodeString(strings.ToLower(s))
+	if err != nil || len(b) != len(x) {
+		return x, fmt.Errorf("%w: xid %q", ErrInvalidFormat, s)
+	}
+	copy(x[:], b)
+	return x, nil
+}
+
+func (x XID) String() string {
+	return xidEncoding.EncodeToString(x[:])
+}
+
+func (x XID) Bytes() []byte {
+	return x[:]
+}
+
+// Time 生成时间 (秒精度)
+func (x XID) Time() time.Time {
+	ts := int64(x[0])<<24 | int64(x[1])<<16 | int64(x[2])<<8 | int64(x[3])
+	return time.Unix(ts, 0)
+}
+
+// Machine 机器码
+func (x XID) Machine() []byte {
+	return x[4:7]
+}
+
+// Pid 进程ID
+func (x XID) Pid() uint16 {
+	return uint16(x[7])<<8 | uint16(x[8])
+}
+
+// Counter 计数器
+func (x XID) Counter() uint32 {
+	return uint32(x[9])<<16 | uint32(x[10])<<8 | uint32(x[11])
+}
+
+// ============================================================
+
+// XIDGenerator XID 生成器
+//
+// 机器码取主机名 SHA-256 前3字节, 计数器以随机值起始
+type XIDGenerator struct {
+	machine [3]byte
+	pid     uint16
+	counter atomic.Uint32
+
+	clock func() time.Time
+}
+
+func NewXID() *XIDGenerator {
+	g := &XIDGenerator{pid: uint16(os.Getpid()), clock: time.Now}
+	host, _ := os.Hostname()
+	if host == "" {
+		_ = randRead(g.machine[:])
+	} else {
+		sum := sha256.Sum256([]byte(host))
+		copy(g.machine[:], sum[:3])
+	}
+	var seed [3]byte
+	_ = randRead(seed[:])
+	g.counter.Store(uint32(seed[0])<<16 | uint32(seed[1])<<8 | uint32(seed[2]))
+	return g
+}
+
+func (g *XIDGenerator) Next() (Identifier, error) {
+	var x XID
+	ts := uint32(g.clock().Unix())
+	x[0] = byte(ts >> 24)
+	x[1] = byte(ts >> 16)
+	x[2] = byte(ts >> 8)
+	x[3] = byte(ts)
+	copy(x[4:7], g.machine[:])
+	x[7] = byte(g.pid >> 8)
+	x[8] = byte(g.pid)
+	c := g.counter.Add(1)
+	x[9] = byte(c >> 16)
+	x[10] = byte(c >> 8)
+	x[11] = byte(c)
+	return x, nil
+}
+
+func (g *XIDGenerator) Parse(s string) (Identifier, error) {
+	return ParseXID(s)
+}
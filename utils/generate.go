@@ -8,6 +8,8 @@ const (
 )
 
 // RandStr 生成指定长度的 随机(字母+数值)字符串
+//
+//	注: 使用 math/rand/v2 非密码学安全, 邀请码等安全相关场景请使用 idgen.NewNanoid
 func RandStr[T Number](n T) string {
 	resBytes := make([]byte, n)
 	size := len(StringLetter)